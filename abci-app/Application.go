@@ -39,14 +39,42 @@ type Application struct {
 }
 
 func NewApplication() *Application {
+	return NewApplicationFromGenesis(nil)
+}
+
+// NewApplicationFromGenesis constructs an in-memory application whose chain starts with genesis
+func NewApplicationFromGenesis(genesis *state.Genesis) *Application {
 	tree := iavl.NewIAVLTree(0, nil)
-	return &Application{state: state.NewStateFromTree(tree)}
+	app := &Application{state: state.NewStateFromTree(tree)}
+	app.state.Genesis = genesis
+	// the tree is empty, so this doesn't depend on the block in which it happens
+	if err := app.state.EnsureGenesis(); err != nil {
+		log.Print("failed to store the genesis: ", err)
+	}
+	return app
 }
 
 func (app *Application) Info() (resInfo types.ResponseInfo) {
 	return types.ResponseInfo{Data: cmn.Fmt("{\"size\":%v}", app.state.Tree.Size())}
 }
 
+func (app *Application) BeginBlock(req types.RequestBeginBlock) {
+	app.state.Height = req.GetHeader().GetHeight()
+	app.state.Time = req.GetHeader().GetTime()
+	if err := app.state.EnsureGenesis(); err != nil {
+		log.Print("failed to store the genesis: ", err)
+	}
+	if err := app.state.EnsureSecretIndex(); err != nil {
+		log.Print("failed to build the secret index: ", err)
+	}
+	if err := app.state.ExecuteRecoveries(); err != nil {
+		log.Print("failed to execute account recoveries: ", err)
+	}
+	if err := app.state.ExpireProposals(); err != nil {
+		log.Print("failed to expire proposals: ", err)
+	}
+}
+
 func (app *Application) DeliverTx(txBytes []byte) types.Result {
 	tx := &transaction.Transaction{}
 	if err := tx.FromBytes(txBytes); err != nil {
@@ -66,6 +94,30 @@ func (app *Application) DeliverTx(txBytes []byte) types.Result {
 				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
 			}
 		}
+	case transaction.AccountRotateKey:
+		{
+			if err := deliverAccountRotateKeyTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountGuardiansSet:
+		{
+			if err := deliverAccountGuardiansSetTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountRecover:
+		{
+			if err := deliverAccountRecoverTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountRecoverCancel:
+		{
+			if err := deliverAccountRecoverCancelTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
 	case transaction.ReputationGive:
 		{
 			if err := deliverReputationGiveTransaction(tx, app.state); err != nil {
@@ -96,6 +148,72 @@ func (app *Application) DeliverTx(txBytes []byte) types.Result {
 				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
 			}
 		}
+	case transaction.SecretUnshare:
+		{
+			if err := deliverSecretUnshareTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretRotateKey:
+		{
+			if err := deliverSecretRotateKeyTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockRequest:
+		{
+			if err := deliverSecretUnlockRequestTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockContribute:
+		{
+			if err := deliverSecretUnlockContributeTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockClose:
+		{
+			if err := deliverSecretUnlockCloseTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretPropose:
+		{
+			if err := deliverSecretProposeTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretApprove:
+		{
+			if err := deliverSecretApproveTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupCreate:
+		{
+			if err := deliverGroupCreateTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupMemberAdd:
+		{
+			if err := deliverGroupMemberAddTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupMemberRemove:
+		{
+			if err := deliverGroupMemberRemoveTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.ParamsSet:
+		{
+			if err := deliverParamsSetTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
 	default:
 		{
 			return types.Result{Code: types.CodeType_BaseInvalidInput, Log: "unknown transaction type"}
@@ -123,6 +241,30 @@ func (app *Application) CheckTx(txBytes []byte) types.Result {
 				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
 			}
 		}
+	case transaction.AccountRotateKey:
+		{
+			if err := checkAccountRotateKeyTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountGuardiansSet:
+		{
+			if err := checkAccountGuardiansSetTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountRecover:
+		{
+			if err := checkAccountRecoverTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.AccountRecoverCancel:
+		{
+			if err := checkAccountRecoverCancelTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
 	case transaction.ReputationGive:
 		{
 			if err := checkReputationGiveTransaction(tx, app.state); err != nil {
@@ -153,6 +295,72 @@ func (app *Application) CheckTx(txBytes []byte) types.Result {
 				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
 			}
 		}
+	case transaction.SecretUnshare:
+		{
+			if err := checkSecretUnshareTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretRotateKey:
+		{
+			if err := checkSecretRotateKeyTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockRequest:
+		{
+			if err := checkSecretUnlockRequestTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockContribute:
+		{
+			if err := checkSecretUnlockContributeTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretUnlockClose:
+		{
+			if err := checkSecretUnlockCloseTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretPropose:
+		{
+			if err := checkSecretProposeTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.SecretApprove:
+		{
+			if err := checkSecretApproveTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupCreate:
+		{
+			if err := checkGroupCreateTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupMemberAdd:
+		{
+			if err := checkGroupMemberAddTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.GroupMemberRemove:
+		{
+			if err := checkGroupMemberRemoveTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
+	case transaction.ParamsSet:
+		{
+			if err := checkParamsSetTransaction(tx, app.state); err != nil {
+				return types.Result{Code: types.CodeType_BaseInvalidInput, Log: err.Error()}
+			}
+		}
 	default:
 		{
 			return types.Result{Code: types.CodeType_BaseInvalidInput, Log: "unknown transaction type"}
@@ -175,8 +383,14 @@ func (app *Application) Query(reqQuery types.RequestQuery) (resQuery types.Respo
 				result interface{}
 				err    error
 			)
-			if reqQuery.Data == nil {
-				result, err = app.state.ListAccounts()
+			if len(reqQuery.Data) == 0 {
+				// only the first page, use /account/list to page through all of them
+				opts, _ := listOptions(nil)
+				var page *state.AccountPage
+				page, err = app.state.ListAccounts(opts)
+				if page != nil {
+					result = page.Accounts
+				}
 				log.Printf("got account list: %+v", result)
 			} else {
 				result, err = app.state.GetAccount(string(reqQuery.Data))
@@ -196,8 +410,14 @@ func (app *Application) Query(reqQuery types.RequestQuery) (resQuery types.Respo
 				result interface{}
 				err    error
 			)
-			if reqQuery.Data == nil {
-				result, err = app.state.ListSecrets()
+			if len(reqQuery.Data) == 0 {
+				// only the first page, use /secret/list to page through all of them
+				opts, _ := listOptions(nil)
+				var page *state.SecretPage
+				page, err = app.state.ListSecrets(opts)
+				if page != nil {
+					result = page.Secrets
+				}
 				log.Printf("got secret list: %+v", result)
 			} else {
 				result, err = app.state.GetSecret(string(reqQuery.Data))
@@ -211,6 +431,146 @@ func (app *Application) Query(reqQuery types.RequestQuery) (resQuery types.Respo
 			bs, _ := json.Marshal(result)
 			resQuery.Value = bs
 		}
+	case "/account/list":
+		{
+			opts, err := listOptions(reqQuery.Data)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			result, err := app.state.ListAccounts(opts)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/secret/list":
+		{
+			opts, err := listOptions(reqQuery.Data)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			result, err := app.state.ListSecrets(opts)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/account/secrets":
+		{
+			result, err := app.state.GetSharedSecrets(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/group":
+		{
+			result, err := app.state.GetGroup(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/group/list":
+		{
+			opts, err := listOptions(reqQuery.Data)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			result, err := app.state.ListGroups(opts)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/secret/history":
+		{
+			result, err := app.state.GetSecretHistory(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/secret/unlocks":
+		{
+			result, err := app.state.GetUnlockRequests(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/secret/proposals":
+		{
+			result, err := app.state.GetProposals(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/secret/due":
+		{
+			// the time of the current block keeps the answer the same on every node
+			result, err := app.state.ListDueSecrets(string(reqQuery.Data), app.state.Time)
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/params":
+		{
+			result, err := app.state.GetParams()
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
+	case "/account/difficulty":
+		{
+			result, err := app.state.GetDifficulty(string(reqQuery.Data))
+			if err != nil {
+				resQuery.Code = types.CodeType_BaseInvalidInput
+				resQuery.Log = err.Error()
+				return
+			}
+			bs, _ := json.Marshal(result)
+			resQuery.Value = bs
+		}
 	default:
 		{
 			resQuery.Code = types.CodeType_BaseInvalidInput
@@ -220,3 +580,20 @@ func (app *Application) Query(reqQuery types.RequestQuery) (resQuery types.Respo
 	}
 	return
 }
+
+// maxListLimit is the maximum page size of list queries
+const maxListLimit = 100
+
+// listOptions decodes the options of a list query and caps the page size
+func listOptions(data []byte) (*state.ListOptions, error) {
+	opts := &state.ListOptions{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Limit <= 0 || opts.Limit > maxListLimit {
+		opts.Limit = maxListLimit
+	}
+	return opts, nil
+}
@@ -0,0 +1,946 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tendermint/abci/types"
+	. "github.com/trusch/passchain/abci-app"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// testProofOfWorkCost is the proof of work cost of the test chains, it keeps the tests fast
+const testProofOfWorkCost byte = 8
+
+func deliver(app *Application, tx *transaction.Transaction) types.Result {
+	bs, err := tx.ToBytes()
+	Expect(err).NotTo(HaveOccurred())
+	return app.DeliverTx(bs)
+}
+
+func prepare(tx *transaction.Transaction, key *crypto.Key, sequence uint64) *transaction.Transaction {
+	tx.Sequence = sequence
+	_, err := tx.ProofOfWork(context.Background(), testProofOfWorkCost)
+	Expect(err).NotTo(HaveOccurred())
+	if key != nil {
+		Expect(tx.Sign(key)).To(Succeed())
+	}
+	return tx
+}
+
+// proveGroupKey signs the proof of a group-create transaction with the given sequence
+func proveGroupKey(tx *transaction.Transaction, key *crypto.Key, sequence uint64) {
+	data := tx.Data.(*transaction.GroupCreateData)
+	tx.Sequence = sequence
+	hash, err := data.ProofHash(tx)
+	Expect(err).NotTo(HaveOccurred())
+	data.Proof, err = key.Sign(hash)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func createAccount(app *Application, id string) *crypto.Key {
+	key, err := crypto.CreateKeyPair()
+	Expect(err).NotTo(HaveOccurred())
+	tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{
+		Account: &state.Account{ID: id, PubKey: key.GetPubString()},
+	})
+	Expect(deliver(app, prepare(tx, key, 0)).IsOK()).To(BeTrue())
+	return key
+}
+
+func createSecret(app *Application, id, owner string, key *crypto.Key, sequence uint64) {
+	tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{
+		Secret: &state.Secret{
+			ID:     id,
+			Value:  "value",
+			Shares: map[string]string{owner: "key"},
+			Owners: map[string]bool{owner: true},
+		},
+		SenderID: owner,
+	})
+	Expect(deliver(app, prepare(tx, key, sequence)).IsOK()).To(BeTrue())
+}
+
+func share(app *Application, id, from string, key *crypto.Key, to string, role state.Role, sequence uint64) types.Result {
+	tx := transaction.New(transaction.SecretShare, &transaction.SecretShareData{
+		ID:        id,
+		SenderID:  from,
+		AccountID: to,
+		Key:       to + "s key",
+		Role:      role,
+	})
+	return deliver(app, prepare(tx, key, sequence))
+}
+
+func getSharedSecrets(app *Application, account string) *state.SharedSecrets {
+	res := app.Query(types.RequestQuery{Path: "/account/secrets", Data: []byte(account)})
+	Expect(res.Code).To(Equal(types.CodeType_OK))
+	shared := &state.SharedSecrets{}
+	Expect(json.Unmarshal(res.Value, shared)).To(Succeed())
+	return shared
+}
+
+func getSecret(app *Application, id string) *state.Secret {
+	res := app.Query(types.RequestQuery{Path: "/secret", Data: []byte(id)})
+	Expect(res.Code).To(Equal(types.CodeType_OK))
+	secret := &state.Secret{}
+	Expect(json.Unmarshal(res.Value, secret)).To(Succeed())
+	return secret
+}
+
+func getAccount(app *Application, id string) *state.Account {
+	res := app.Query(types.RequestQuery{Path: "/account", Data: []byte(id)})
+	Expect(res.Code).To(Equal(types.CodeType_OK))
+	acc := &state.Account{}
+	Expect(json.Unmarshal(res.Value, acc)).To(Succeed())
+	return acc
+}
+
+func rotateKey(app *Application, data *transaction.AccountRotateKeyData, oldKey, newKey *crypto.Key, sequence uint64) types.Result {
+	data.PubKey = newKey.GetPubString()
+	proof, err := newKey.Sign(data.ApprovalHash(sequence))
+	Expect(err).NotTo(HaveOccurred())
+	data.Proof = proof
+	tx := transaction.New(transaction.AccountRotateKey, data)
+	return deliver(app, prepare(tx, oldKey, sequence))
+}
+
+var _ = Describe("Application", func() {
+	var (
+		app      *Application
+		aliceKey *crypto.Key
+		bobKey   *crypto.Key
+	)
+
+	start := func(params *state.Params) {
+		app = NewApplicationFromGenesis(&state.Genesis{Params: params})
+		aliceKey = createAccount(app, "alice")
+		bobKey = createAccount(app, "bob")
+		createSecret(app, "secret", "alice", aliceKey, 1)
+	}
+
+	BeforeEach(func() {
+		start(&state.Params{ProofOfWorkCost: testProofOfWorkCost})
+	})
+
+	It("should deliver valid transactions", func() {
+		tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+			Secret: &state.Secret{
+				ID:     "secret",
+				Value:  "new value",
+				Shares: map[string]string{"alice": "key"},
+				Owners: map[string]bool{"alice": true},
+			},
+			SenderID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Value).To(Equal("new value"))
+	})
+
+	It("should store the metadata of a secret", func() {
+		meta := &state.SecretMetadata{
+			URL:       "https://example.com",
+			Tags:      []string{"web"},
+			Fields:    map[string]string{"pin": "encrypted pin"},
+			Encrypted: []string{"fields.pin"},
+		}
+		tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+			Secret: &state.Secret{
+				ID:       "secret",
+				Value:    "new value",
+				Shares:   map[string]string{"alice": "key"},
+				Owners:   map[string]bool{"alice": true},
+				Metadata: meta,
+			},
+			SenderID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Metadata).To(Equal(meta))
+	})
+
+	It("should list secrets which are due for rotation", func() {
+		tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+			Secret: &state.Secret{
+				ID:          "secret",
+				Value:       "new value",
+				Shares:      map[string]string{"alice": "key"},
+				Owners:      map[string]bool{"alice": true},
+				RotateEvery: 60,
+			},
+			SenderID: "alice",
+		})
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 42, Time: 1000}})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").RotatedAt).To(Equal(uint64(1000)))
+
+		due := func(account string) []*state.Secret {
+			res := app.Query(types.RequestQuery{Path: "/secret/due", Data: []byte(account)})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			secrets := []*state.Secret{}
+			Expect(json.Unmarshal(res.Value, &secrets)).To(Succeed())
+			return secrets
+		}
+		// the query uses the time of the current block, not the clock of the node
+		Expect(due("alice")).To(BeEmpty())
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 43, Time: 1060}})
+		Expect(due("alice")).To(HaveLen(1))
+		Expect(due("bob")).To(BeEmpty())
+	})
+
+	It("should list secrets by prefix", func() {
+		createSecret(app, "web/mail", "alice", aliceKey, 2)
+		createSecret(app, "web/shop", "alice", aliceKey, 3)
+		createSecret(app, "wifi", "alice", aliceKey, 4)
+		for prefix, expected := range map[string]int{"": 4, "w": 3, "web/": 2, "web/mail": 1, "db": 0} {
+			res := app.Query(types.RequestQuery{Path: "/secret/list", Data: []byte(`{"prefix":"` + prefix + `"}`)})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			page := &state.SecretPage{}
+			Expect(json.Unmarshal(res.Value, page)).To(Succeed())
+			Expect(page.Secrets).To(HaveLen(expected), "prefix %v", prefix)
+		}
+	})
+
+	It("should list secrets in pages", func() {
+		for i, id := range []string{"a", "b", "c", "d"} {
+			createSecret(app, id, "alice", aliceKey, uint64(i+2))
+		}
+		ids := []string{}
+		opts := &state.ListOptions{Start: "b", Limit: 2}
+		for {
+			data, _ := json.Marshal(opts)
+			res := app.Query(types.RequestQuery{Path: "/secret/list", Data: data})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			page := &state.SecretPage{}
+			Expect(json.Unmarshal(res.Value, page)).To(Succeed())
+			Expect(len(page.Secrets)).To(BeNumerically("<=", 2))
+			for _, secret := range page.Secrets {
+				ids = append(ids, secret.ID)
+			}
+			if page.Next == "" {
+				break
+			}
+			opts.Start = page.Next
+		}
+		Expect(ids).To(Equal([]string{"b", "c", "d", "secret"}))
+	})
+
+	It("should only return the first page of the account list without query data", func() {
+		for i := 0; i < 100; i++ {
+			createAccount(app, fmt.Sprintf("account-%03d", i))
+		}
+		res := app.Query(types.RequestQuery{Path: "/account"})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		accounts := []*state.Account{}
+		Expect(json.Unmarshal(res.Value, &accounts)).To(Succeed())
+		Expect(accounts).To(HaveLen(100))
+	})
+
+	It("should keep the history of a secret", func() {
+		tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+			Secret: &state.Secret{
+				ID:     "secret",
+				Value:  "new value",
+				Shares: map[string]string{"alice": "key"},
+				Owners: map[string]bool{"alice": true},
+			},
+			SenderID: "alice",
+		})
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 42}})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Version).To(Equal(uint64(2)))
+
+		res := app.Query(types.RequestQuery{Path: "/secret/history", Data: []byte("secret")})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		versions := []*state.SecretVersion{}
+		Expect(json.Unmarshal(res.Value, &versions)).To(Succeed())
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[0].Secret.Value).To(Equal("value"))
+		Expect(versions[1].Secret.Value).To(Equal("new value"))
+		Expect(versions[1].Author).To(Equal("alice"))
+		Expect(versions[1].Height).To(Equal(uint64(42)))
+	})
+
+	It("should reject an account-add which is not signed by the supplied key", func() {
+		otherKey, _ := crypto.CreateKeyPair()
+		tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{
+			Account: &state.Account{ID: "mallory", PubKey: aliceKey.GetPubString()},
+		})
+		Expect(deliver(app, prepare(tx, otherKey, 0)).IsErr()).To(BeTrue())
+	})
+
+	It("should reject a secret-update from an account which is not an owner", func() {
+		tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+			Secret: &state.Secret{
+				ID:     "secret",
+				Value:  "hijacked",
+				Shares: map[string]string{"bob": "key"},
+				Owners: map[string]bool{"bob": true},
+			},
+			SenderID: "bob",
+		})
+		Expect(deliver(app, prepare(tx, bobKey, 1)).IsErr()).To(BeTrue())
+		Expect(getSecret(app, "secret").Value).To(Equal("value"))
+	})
+
+	It("should reject a secret-del signed by the wrong key", func() {
+		tx := transaction.New(transaction.SecretDel, &transaction.SecretDelData{
+			ID:       "secret",
+			SenderID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, bobKey, 2)).IsErr()).To(BeTrue())
+		Expect(getSecret(app, "secret").Value).To(Equal("value"))
+	})
+
+	It("should not replay old transactions of a deleted account which is registered again", func() {
+		del := transaction.New(transaction.SecretDel, &transaction.SecretDelData{ID: "secret", SenderID: "alice"})
+		Expect(deliver(app, prepare(del, aliceKey, 2)).IsOK()).To(BeTrue())
+		tx := transaction.New(transaction.AccountDel, &transaction.AccountDelData{ID: "alice"})
+		Expect(deliver(app, prepare(tx, aliceKey, 3)).IsOK()).To(BeTrue())
+
+		add := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{
+			Account: &state.Account{ID: "alice", PubKey: aliceKey.GetPubString()},
+		})
+		Expect(deliver(app, prepare(add, aliceKey, 0)).IsOK()).To(BeTrue())
+		Expect(getAccount(app, "alice").Sequence).To(Equal(uint64(3)))
+		createSecret(app, "secret", "alice", aliceKey, 4)
+		Expect(deliver(app, del).IsErr()).To(BeTrue())
+		Expect(deliver(app, tx).IsErr()).To(BeTrue())
+	})
+
+	It("should reject an account-del without signature", func() {
+		tx := transaction.New(transaction.AccountDel, &transaction.AccountDelData{ID: "alice"})
+		Expect(deliver(app, prepare(tx, nil, 2)).IsErr()).To(BeTrue())
+	})
+
+	It("should reject a give-reputation in the name of another account", func() {
+		tx := transaction.New(transaction.ReputationGive, &transaction.ReputationGiveData{
+			From:  "alice",
+			To:    "bob",
+			Value: 3,
+		})
+		Expect(deliver(app, prepare(tx, bobKey, 2)).IsErr()).To(BeTrue())
+	})
+
+	It("should reject a give-reputation to the sender itself", func() {
+		tx := transaction.New(transaction.ReputationGive, &transaction.ReputationGiveData{
+			From:  "alice",
+			To:    "alice",
+			Value: 3,
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsErr()).To(BeTrue())
+	})
+
+	It("should reject transactions without proof of work", func() {
+		tx := transaction.New(transaction.SecretDel, &transaction.SecretDelData{
+			ID:       "secret",
+			SenderID: "alice",
+		})
+		tx.Sequence = 2
+		Expect(tx.Sign(aliceKey)).To(Succeed())
+		for tx.VerifyProofOfWork(testProofOfWorkCost) == nil {
+			tx.Nonce++
+		}
+		Expect(deliver(app, tx).IsErr()).To(BeTrue())
+	})
+
+	It("should reject replayed transactions", func() {
+		tx := transaction.New(transaction.ReputationGive, &transaction.ReputationGiveData{
+			From:  "alice",
+			To:    "bob",
+			Value: 3,
+		})
+		prepare(tx, aliceKey, 2)
+		Expect(deliver(app, tx).IsOK()).To(BeTrue())
+		Expect(deliver(app, tx).IsErr()).To(BeTrue())
+	})
+
+	It("should share and unshare a secret", func() {
+		share := transaction.New(transaction.SecretShare, &transaction.SecretShareData{
+			ID:        "secret",
+			SenderID:  "alice",
+			AccountID: "bob",
+			Key:       "bobs key",
+		})
+		Expect(deliver(app, prepare(share, aliceKey, 2)).IsOK()).To(BeTrue())
+		secret := getSecret(app, "secret")
+		Expect(secret.Shares).To(HaveKeyWithValue("bob", "bobs key"))
+		Expect(secret.Owners).NotTo(HaveKey("bob"))
+
+		// a bare unshare would leave bob with the current data key
+		unshare := transaction.New(transaction.SecretUnshare, &transaction.SecretUnshareData{
+			ID:        "secret",
+			SenderID:  "alice",
+			AccountID: "bob",
+		})
+		Expect(deliver(app, prepare(unshare, aliceKey, 3)).IsErr()).To(BeTrue())
+		rekeyed := getSecret(app, "secret")
+		delete(rekeyed.Shares, "bob")
+		delete(rekeyed.Roles, "bob")
+		unshare.Data.(*transaction.SecretUnshareData).Secret = rekeyed
+		Expect(deliver(app, prepare(unshare, aliceKey, 3)).IsErr()).To(BeTrue())
+		rekeyed.Shares["alice"] = "new key"
+		Expect(deliver(app, prepare(unshare, aliceKey, 3)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Shares).NotTo(HaveKey("bob"))
+	})
+
+	It("should let writers update the value but not the shares", func() {
+		Expect(share(app, "secret", "alice", aliceKey, "bob", state.RoleWriter, 2).IsOK()).To(BeTrue())
+		secret := getSecret(app, "secret")
+		Expect(secret.Roles).To(Equal(map[string]state.Role{"alice": state.RoleAdmin, "bob": state.RoleWriter}))
+
+		secret.Value = "new value"
+		update := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "bob"})
+		Expect(deliver(app, prepare(update, bobKey, 1)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Value).To(Equal("new value"))
+
+		secret = getSecret(app, "secret")
+		secret.Roles["bob"] = state.RoleAdmin
+		update = transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "bob"})
+		Expect(deliver(app, prepare(update, bobKey, 2)).IsErr()).To(BeTrue())
+
+		// a writer can't replace the data keys of others
+		secret = getSecret(app, "secret")
+		secret.Shares["alice"] = "garbage"
+		update = transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "bob"})
+		Expect(deliver(app, prepare(update, bobKey, 2)).IsErr()).To(BeTrue())
+		rotate := transaction.New(transaction.SecretRotateKey, &transaction.SecretRotateKeyData{Secret: secret, SenderID: "bob"})
+		Expect(deliver(app, prepare(rotate, bobKey, 2)).IsErr()).To(BeTrue())
+
+		// a writer can't change the approval policy either
+		secret = getSecret(app, "secret")
+		secret.RequiredApprovals = 1
+		update = transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "bob"})
+		Expect(deliver(app, prepare(update, bobKey, 2)).IsErr()).To(BeTrue())
+		Expect(getSecret(app, "secret").RequiredApprovals).To(Equal(0))
+
+		del := transaction.New(transaction.SecretDel, &transaction.SecretDelData{ID: "secret", SenderID: "bob"})
+		Expect(deliver(app, prepare(del, bobKey, 2)).IsErr()).To(BeTrue())
+	})
+
+	It("should let sharers grant only the reader role", func() {
+		carolKey := createAccount(app, "carol")
+		Expect(share(app, "secret", "alice", aliceKey, "bob", state.RoleSharer, 2).IsOK()).To(BeTrue())
+		Expect(share(app, "secret", "bob", bobKey, "carol", state.RoleWriter, 1).IsErr()).To(BeTrue())
+		Expect(share(app, "secret", "bob", bobKey, "carol", state.RoleReader, 1).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").RoleOf("carol")).To(Equal(state.RoleReader))
+		Expect(share(app, "secret", "carol", carolKey, "alice", state.RoleReader, 1).IsErr()).To(BeTrue())
+
+		// only admins can change roles and the last admin can't be demoted
+		Expect(share(app, "secret", "alice", aliceKey, "carol", state.RoleWriter, 3).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").RoleOf("carol")).To(Equal(state.RoleWriter))
+		Expect(share(app, "secret", "alice", aliceKey, "alice", state.RoleReader, 4).IsErr()).To(BeTrue())
+	})
+
+	It("should index the secrets shared with an account", func() {
+		Expect(getSharedSecrets(app, "alice").Owned).To(Equal([]string{"secret"}))
+		Expect(getSharedSecrets(app, "bob").Readable).To(BeEmpty())
+
+		share := transaction.New(transaction.SecretShare, &transaction.SecretShareData{
+			ID:        "secret",
+			SenderID:  "alice",
+			AccountID: "bob",
+			Key:       "bobs key",
+		})
+		Expect(deliver(app, prepare(share, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(getSharedSecrets(app, "bob").Readable).To(Equal([]string{"secret"}))
+		Expect(getSharedSecrets(app, "bob").Owned).To(BeEmpty())
+
+		del := transaction.New(transaction.SecretDel, &transaction.SecretDelData{ID: "secret", SenderID: "alice"})
+		Expect(deliver(app, prepare(del, aliceKey, 3)).IsOK()).To(BeTrue())
+		Expect(getSharedSecrets(app, "alice").Owned).To(BeEmpty())
+		Expect(getSharedSecrets(app, "bob").Readable).To(BeEmpty())
+	})
+
+	It("should rotate the key of a secret when unsharing it", func() {
+		Expect(share(app, "secret", "alice", aliceKey, "bob", state.RoleReader, 2).IsOK()).To(BeTrue())
+		rekeyed := getSecret(app, "secret")
+		rekeyed.Value = "value with new key"
+		rekeyed.Shares["alice"] = "new key"
+
+		// the remaining shares must stay the same
+		unshare := transaction.New(transaction.SecretUnshare, &transaction.SecretUnshareData{
+			ID:        "secret",
+			SenderID:  "alice",
+			AccountID: "bob",
+			Secret:    rekeyed,
+		})
+		Expect(deliver(app, prepare(unshare, aliceKey, 3)).IsErr()).To(BeTrue())
+
+		delete(rekeyed.Shares, "bob")
+		delete(rekeyed.Roles, "bob")
+		Expect(deliver(app, prepare(unshare, aliceKey, 3)).IsOK()).To(BeTrue())
+		secret := getSecret(app, "secret")
+		Expect(secret.Value).To(Equal("value with new key"))
+		Expect(secret.Shares).To(Equal(map[string]string{"alice": "new key"}))
+
+		rekeyed = getSecret(app, "secret")
+		rekeyed.Shares["alice"] = "newer key"
+		rotate := transaction.New(transaction.SecretRotateKey, &transaction.SecretRotateKeyData{Secret: rekeyed, SenderID: "alice"})
+		Expect(deliver(app, prepare(rotate, aliceKey, 4)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "secret").Shares).To(Equal(map[string]string{"alice": "newer key"}))
+	})
+
+	It("should reject a secret-unshare from an account which is not an owner", func() {
+		tx := transaction.New(transaction.SecretUnshare, &transaction.SecretUnshareData{
+			ID:        "secret",
+			SenderID:  "bob",
+			AccountID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, bobKey, 1)).IsErr()).To(BeTrue())
+		Expect(getSecret(app, "secret").Shares).To(HaveKey("alice"))
+	})
+
+	It("should rotate the key of an account", func() {
+		Expect(share(app, "secret", "alice", aliceKey, "bob", state.RoleReader, 2).IsOK()).To(BeTrue())
+		newKey, err := crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+
+		// all shares of the account must be re-encrypted
+		data := &transaction.AccountRotateKeyData{ID: "bob"}
+		Expect(rotateKey(app, data, bobKey, newKey, 1).IsErr()).To(BeTrue())
+
+		data.Shares = map[string]string{"secret": "bobs new key"}
+		Expect(rotateKey(app, data, newKey, newKey, 1).IsErr()).To(BeTrue())
+
+		// the sender must own the new key
+		data.PubKey = newKey.GetPubString()
+		data.Proof, err = bobKey.Sign(data.ApprovalHash(1))
+		Expect(err).NotTo(HaveOccurred())
+		tx := transaction.New(transaction.AccountRotateKey, data)
+		Expect(deliver(app, prepare(tx, bobKey, 1)).IsErr()).To(BeTrue())
+
+		Expect(rotateKey(app, data, bobKey, newKey, 1).IsOK()).To(BeTrue())
+		Expect(getAccount(app, "bob").PubKey).To(Equal(newKey.GetPubString()))
+		Expect(getSecret(app, "secret").Shares["bob"]).To(Equal("bobs new key"))
+
+		// the old key is not valid anymore
+		tx = transaction.New(transaction.AccountDel, &transaction.AccountDelData{ID: "bob"})
+		Expect(deliver(app, prepare(tx, bobKey, 2)).IsErr()).To(BeTrue())
+	})
+
+	It("should require the approval of guardians to rotate a key", func() {
+		carolKey, err := crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{
+			Account: &state.Account{ID: "carol", PubKey: carolKey.GetPubString(), Guardians: []string{"alice", "bob"}, GuardianThreshold: 1},
+		})
+		Expect(deliver(app, prepare(tx, carolKey, 0)).IsOK()).To(BeTrue())
+		newKey, err := crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+
+		data := &transaction.AccountRotateKeyData{ID: "carol"}
+		Expect(rotateKey(app, data, carolKey, newKey, 1).IsErr()).To(BeTrue())
+
+		data.PubKey = newKey.GetPubString()
+		approval, err := aliceKey.Sign(data.ApprovalHash(1))
+		Expect(err).NotTo(HaveOccurred())
+		data.Approvals = map[string]string{"bob": approval}
+		Expect(rotateKey(app, data, carolKey, newKey, 1).IsErr()).To(BeTrue())
+		data.Approvals = map[string]string{"alice": approval}
+		Expect(rotateKey(app, data, carolKey, newKey, 1).IsOK()).To(BeTrue())
+	})
+
+	It("should let guardians recover an account", func() {
+		tx := transaction.New(transaction.AccountGuardiansSet, &transaction.AccountGuardiansSetData{
+			ID:                "alice",
+			Guardians:         []string{"bob"},
+			GuardianThreshold: 1,
+			RecoveryDelay:     100,
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		// the transaction counts towards the budget of alice like any other
+		Expect(getAccount(app, "alice").WindowTxs).To(Equal(2))
+		newKey, err := crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		recoverTx := transaction.New(transaction.AccountRecover, &transaction.AccountRecoverData{
+			ID:       "alice",
+			SenderID: "bob",
+			PubKey:   newKey.GetPubString(),
+		})
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 1, Time: 1000}})
+		Expect(deliver(app, prepare(recoverTx, bobKey, 1)).IsOK()).To(BeTrue())
+
+		// the owner can cancel the recovery during the delay
+		tx = transaction.New(transaction.AccountRecoverCancel, &transaction.AccountRecoverCancelData{ID: "alice"})
+		Expect(deliver(app, prepare(tx, aliceKey, 3)).IsOK()).To(BeTrue())
+		Expect(getAccount(app, "alice").Recovery).To(BeNil())
+
+		Expect(deliver(app, prepare(recoverTx, bobKey, 2)).IsOK()).To(BeTrue())
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 2, Time: 1099}})
+		Expect(getAccount(app, "alice").PubKey).To(Equal(aliceKey.GetPubString()))
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: 3, Time: 1100}})
+		Expect(getAccount(app, "alice").PubKey).To(Equal(newKey.GetPubString()))
+
+		tx = transaction.New(transaction.AccountDel, &transaction.AccountDelData{ID: "alice"})
+		Expect(deliver(app, prepare(tx, aliceKey, 4)).IsErr()).To(BeTrue())
+		Expect(deliver(app, prepare(tx, newKey, 4)).IsOK()).To(BeTrue())
+	})
+
+	It("should collect the key shares of a threshold secret", func() {
+		secret := &state.Secret{
+			ID:        "root",
+			Value:     "value",
+			Shares:    map[string]string{"alice": "alices part", "bob": "bobs part"},
+			Roles:     map[string]state.Role{"alice": state.RoleAdmin, "bob": state.RoleReader},
+			Threshold: 3,
+		}
+		tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{Secret: secret, SenderID: "alice"})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsErr()).To(BeTrue())
+		secret.Threshold = 2
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		Expect(share(app, "root", "alice", aliceKey, "bob", state.RoleWriter, 3).IsErr()).To(BeTrue())
+
+		contribute := transaction.New(transaction.SecretUnlockContribute, &transaction.SecretUnlockContributeData{
+			ID:          "root",
+			SenderID:    "alice",
+			RequesterID: "bob",
+			Share:       "alices part for bob",
+		})
+		Expect(deliver(app, prepare(contribute, aliceKey, 3)).IsErr()).To(BeTrue())
+		tx = transaction.New(transaction.SecretUnlockRequest, &transaction.SecretUnlockRequestData{ID: "root", SenderID: "bob"})
+		Expect(deliver(app, prepare(tx, bobKey, 1)).IsOK()).To(BeTrue())
+		Expect(deliver(app, prepare(contribute, aliceKey, 3)).IsOK()).To(BeTrue())
+
+		res := app.Query(types.RequestQuery{Path: "/secret/unlocks", Data: []byte("root")})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		requests := []*state.UnlockRequest{}
+		Expect(json.Unmarshal(res.Value, &requests)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Requester).To(Equal("bob"))
+		Expect(requests[0].Contributions).To(Equal(map[string]string{"alice": "alices part for bob"}))
+
+		getRequests := func() []*state.UnlockRequest {
+			res := app.Query(types.RequestQuery{Path: "/secret/unlocks", Data: []byte("root")})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			requests := []*state.UnlockRequest{}
+			Expect(json.Unmarshal(res.Value, &requests)).To(Succeed())
+			return requests
+		}
+		closeTx := transaction.New(transaction.SecretUnlockClose, &transaction.SecretUnlockCloseData{ID: "root", SenderID: "bob"})
+		Expect(deliver(app, prepare(closeTx, bobKey, 2)).IsOK()).To(BeTrue())
+		Expect(getRequests()).To(BeEmpty())
+		Expect(deliver(app, prepare(closeTx, bobKey, 3)).IsErr()).To(BeTrue())
+
+		// a new value needs a new data key, which drops the contributions to the old one
+		Expect(deliver(app, prepare(tx, bobKey, 3)).IsOK()).To(BeTrue())
+		Expect(deliver(app, prepare(contribute, aliceKey, 4)).IsOK()).To(BeTrue())
+		secret.Value = "new value"
+		update := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "alice"})
+		Expect(deliver(app, prepare(update, aliceKey, 5)).IsErr()).To(BeTrue())
+		secret.Shares = map[string]string{"alice": "new alices part", "bob": "new bobs part"}
+		Expect(deliver(app, prepare(update, aliceKey, 5)).IsOK()).To(BeTrue())
+		Expect(getRequests()).To(BeEmpty())
+	})
+
+	It("should need the approval of several admins to delete a critical secret", func() {
+		tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{
+			Secret: &state.Secret{
+				ID:                "critical",
+				Value:             "value",
+				Shares:            map[string]string{"alice": "key", "bob": "key"},
+				Roles:             map[string]state.Role{"alice": state.RoleAdmin, "bob": state.RoleAdmin},
+				RequiredApprovals: 2,
+			},
+			SenderID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		del := transaction.New(transaction.SecretDel, &transaction.SecretDelData{ID: "critical", SenderID: "alice"})
+		Expect(deliver(app, prepare(del, aliceKey, 3)).IsErr()).To(BeTrue())
+
+		getProposals := func() []*state.Proposal {
+			res := app.Query(types.RequestQuery{Path: "/secret/proposals", Data: []byte("critical")})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			proposals := []*state.Proposal{}
+			Expect(json.Unmarshal(res.Value, &proposals)).To(Succeed())
+			return proposals
+		}
+
+		// without bob the required approvals can't be reached anymore
+		tx = transaction.New(transaction.SecretPropose, &transaction.SecretProposeData{
+			ID:        "critical",
+			SenderID:  "alice",
+			Action:    state.ProposalUnshare,
+			AccountID: "bob",
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 3)).IsErr()).To(BeTrue())
+		tx = transaction.New(transaction.SecretPropose, &transaction.SecretProposeData{
+			ID:       "critical",
+			SenderID: "alice",
+			Action:   state.ProposalDelete,
+			Version:  2,
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 3)).IsErr()).To(BeTrue())
+		tx.Data.(*transaction.SecretProposeData).Version = 1
+		Expect(deliver(app, prepare(tx, aliceKey, 3)).IsOK()).To(BeTrue())
+		Expect(getProposals()).To(HaveLen(1))
+		// proposals expire
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: state.ProposalLifetime}})
+		Expect(getProposals()).To(BeEmpty())
+
+		Expect(deliver(app, prepare(tx, aliceKey, 4)).IsOK()).To(BeTrue())
+		proposals := getProposals()
+		Expect(proposals).To(HaveLen(1))
+		Expect(getSecret(app, "critical").ID).To(Equal("critical"))
+		approve := transaction.New(transaction.SecretApprove, &transaction.SecretApproveData{
+			ID:         "critical",
+			ProposalID: proposals[0].ID,
+			SenderID:   "bob",
+		})
+		// the proposal can't be executed once the secret changed
+		secret := getSecret(app, "critical")
+		secret.Value = "new value"
+		update := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "alice"})
+		Expect(deliver(app, prepare(update, aliceKey, 5)).IsOK()).To(BeTrue())
+		Expect(deliver(app, prepare(approve, bobKey, 1)).IsErr()).To(BeTrue())
+		tx.Data.(*transaction.SecretProposeData).Version = 2
+		Expect(deliver(app, prepare(tx, aliceKey, 6)).IsOK()).To(BeTrue())
+		approve.Data.(*transaction.SecretApproveData).ProposalID = state.NewProposalID("alice", 6)
+		Expect(deliver(app, prepare(approve, bobKey, 1)).IsOK()).To(BeTrue())
+		res := app.Query(types.RequestQuery{Path: "/secret", Data: []byte("critical")})
+		Expect(res.Code).NotTo(Equal(types.CodeType_OK))
+	})
+
+	It("should need the approval of several admins to raise the required approvals", func() {
+		tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{
+			Secret: &state.Secret{
+				ID:     "critical",
+				Value:  "value",
+				Shares: map[string]string{"alice": "key", "bob": "key"},
+				Roles:  map[string]state.Role{"alice": state.RoleAdmin, "bob": state.RoleAdmin},
+			},
+			SenderID: "alice",
+		})
+		Expect(deliver(app, prepare(tx, aliceKey, 2)).IsOK()).To(BeTrue())
+		secret := getSecret(app, "critical")
+		secret.RequiredApprovals = 2
+		update := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{Secret: secret, SenderID: "alice"})
+		Expect(deliver(app, prepare(update, aliceKey, 3)).IsErr()).To(BeTrue())
+
+		propose := transaction.New(transaction.SecretPropose, &transaction.SecretProposeData{
+			ID:       "critical",
+			SenderID: "alice",
+			Action:   state.ProposalUpdate,
+			Secret:   secret,
+			Version:  secret.Version,
+		})
+		Expect(deliver(app, prepare(propose, aliceKey, 3)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "critical").RequiredApprovals).To(Equal(0))
+		approve := transaction.New(transaction.SecretApprove, &transaction.SecretApproveData{
+			ID:         "critical",
+			ProposalID: state.NewProposalID("alice", 3),
+			SenderID:   "bob",
+		})
+		Expect(deliver(app, prepare(approve, bobKey, 1)).IsOK()).To(BeTrue())
+		Expect(getSecret(app, "critical").RequiredApprovals).To(Equal(2))
+	})
+
+	It("should manage the members of a group", func() {
+		groupKey, _ := crypto.CreateKeyPair()
+		create := transaction.New(transaction.GroupCreate, &transaction.GroupCreateData{
+			Group: &state.Group{
+				ID:     "group",
+				Keys:   map[string]string{"alice": "alices key"},
+				Admins: map[string]bool{"alice": true},
+			},
+			PubKey:   groupKey.GetPubString(),
+			SenderID: "alice",
+		})
+		// the group key must sign the transaction as well
+		Expect(deliver(app, prepare(create, aliceKey, 2)).IsErr()).To(BeTrue())
+		proveGroupKey(create, aliceKey, 2)
+		Expect(deliver(app, prepare(create, aliceKey, 2)).IsErr()).To(BeTrue())
+		proveGroupKey(create, groupKey, 2)
+		Expect(deliver(app, prepare(create, aliceKey, 2)).IsOK()).To(BeTrue())
+		res := app.Query(types.RequestQuery{Path: "/account", Data: []byte("group")})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+
+		// the id is taken now
+		create.Data.(*transaction.GroupCreateData).SenderID = "bob"
+		create.Data.(*transaction.GroupCreateData).Group.Admins = map[string]bool{"bob": true}
+		create.Data.(*transaction.GroupCreateData).Group.Keys = map[string]string{"bob": "bobs key"}
+		Expect(deliver(app, prepare(create, bobKey, 1)).IsErr()).To(BeTrue())
+
+		add := transaction.New(transaction.GroupMemberAdd, &transaction.GroupMemberAddData{
+			ID:        "group",
+			SenderID:  "alice",
+			AccountID: "bob",
+			Key:       "bobs key",
+		})
+		Expect(deliver(app, prepare(add, aliceKey, 3)).IsOK()).To(BeTrue())
+
+		removeAlice := transaction.New(transaction.GroupMemberRemove, &transaction.GroupMemberRemoveData{
+			ID:        "group",
+			SenderID:  "bob",
+			AccountID: "alice",
+		})
+		Expect(deliver(app, prepare(removeAlice, bobKey, 1)).IsErr()).To(BeTrue())
+
+		// the key pair of the group is rotated and only the remaining members get the new key
+		newGroupKey, _ := crypto.CreateKeyPair()
+		data := &transaction.GroupMemberRemoveData{
+			ID:        "group",
+			SenderID:  "alice",
+			AccountID: "bob",
+			PubKey:    newGroupKey.GetPubString(),
+		}
+		removeBob := transaction.New(transaction.GroupMemberRemove, data)
+		proveNewGroupKey := func() {
+			removeBob.Sequence = 4
+			hash, err := data.ProofHash(removeBob)
+			Expect(err).NotTo(HaveOccurred())
+			data.Proof, err = newGroupKey.Sign(hash)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		data.MemberKeys = map[string]string{"alice": "alices new key", "bob": "bobs new key"}
+		proveNewGroupKey()
+		Expect(deliver(app, prepare(removeBob, aliceKey, 4)).IsErr()).To(BeTrue())
+		data.MemberKeys = map[string]string{"alice": "alices new key"}
+		Expect(deliver(app, prepare(removeBob, aliceKey, 4)).IsErr()).To(BeTrue())
+		proveNewGroupKey()
+		Expect(deliver(app, prepare(removeBob, aliceKey, 4)).IsOK()).To(BeTrue())
+
+		res = app.Query(types.RequestQuery{Path: "/group", Data: []byte("group")})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		group := &state.Group{}
+		Expect(json.Unmarshal(res.Value, group)).To(Succeed())
+		Expect(group.Keys).To(Equal(map[string]string{"alice": "alices new key"}))
+		res = app.Query(types.RequestQuery{Path: "/account", Data: []byte("group")})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		account := &state.Account{}
+		Expect(json.Unmarshal(res.Value, account)).To(Succeed())
+		Expect(account.PubKey).To(Equal(newGroupKey.GetPubString()))
+	})
+
+	It("should let the admins change the proof of work cost", func() {
+		// the admins are created with the chain, so nobody can register their ids first
+		var err error
+		aliceKey, err = crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		bobKey, err = crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		app = NewApplicationFromGenesis(&state.Genesis{
+			Params: &state.Params{
+				ProofOfWorkCost: testProofOfWorkCost,
+				Admins:          []string{"alice", "bob"},
+				AdminThreshold:  2,
+			},
+			Accounts: []*state.Account{
+				{ID: "alice", PubKey: aliceKey.GetPubString()},
+				{ID: "bob", PubKey: bobKey.GetPubString()},
+			},
+		})
+		Expect(getAccount(app, "alice").PubKey).To(Equal(aliceKey.GetPubString()))
+		res := app.Query(types.RequestQuery{Path: "/params"})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		params := &state.Params{}
+		Expect(json.Unmarshal(res.Value, params)).To(Succeed())
+		Expect(params.ProofOfWorkCost).To(Equal(testProofOfWorkCost))
+
+		data := &transaction.ParamsSetData{
+			SenderID: "alice",
+			Params: &state.Params{
+				Version:         1,
+				ProofOfWorkCost: 4,
+				Admins:          []string{"alice", "bob"},
+				AdminThreshold:  2,
+			},
+		}
+		// bob didn't approve yet
+		Expect(deliver(app, prepare(transaction.New(transaction.ParamsSet, data), aliceKey, 1)).IsErr()).To(BeTrue())
+
+		approval, err := bobKey.Sign(data.ApprovalHash())
+		Expect(err).NotTo(HaveOccurred())
+		data.Approvals = map[string]string{"bob": approval}
+		Expect(deliver(app, prepare(transaction.New(transaction.ParamsSet, data), aliceKey, 1)).IsOK()).To(BeTrue())
+
+		res = app.Query(types.RequestQuery{Path: "/params"})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		Expect(json.Unmarshal(res.Value, params)).To(Succeed())
+		Expect(params.Version).To(Equal(uint64(1)))
+		Expect(params.ProofOfWorkCost).To(Equal(byte(4)))
+
+		// the approval is bound to version 1
+		Expect(deliver(app, prepare(transaction.New(transaction.ParamsSet, data), aliceKey, 2)).IsErr()).To(BeTrue())
+
+		// a single admin can't change the params
+		data.SenderID = "bob"
+		data.Params = &state.Params{Version: 2, ProofOfWorkCost: 0, Admins: []string{"bob"}}
+		data.Approvals = nil
+		Expect(deliver(app, prepare(transaction.New(transaction.ParamsSet, data), bobKey, 1)).IsErr()).To(BeTrue())
+	})
+
+	It("should not let anybody change the params of a chain without admins", func() {
+		data := &transaction.ParamsSetData{
+			SenderID: "alice",
+			Params:   &state.Params{Version: 1, ProofOfWorkCost: testProofOfWorkCost, Admins: []string{"alice"}},
+		}
+		Expect(deliver(app, prepare(transaction.New(transaction.ParamsSet, data), aliceKey, 2)).IsErr()).To(BeTrue())
+		res := app.Query(types.RequestQuery{Path: "/params"})
+		Expect(res.Code).To(Equal(types.CodeType_OK))
+		params := &state.Params{}
+		Expect(json.Unmarshal(res.Value, params)).To(Succeed())
+		Expect(params.Admins).To(BeEmpty())
+	})
+
+	It("should raise the proof of work cost of accounts which exceed their budget", func() {
+		start(&state.Params{ProofOfWorkCost: testProofOfWorkCost, TxBudget: 1})
+		difficulty := func(id string) *state.Difficulty {
+			res := app.Query(types.RequestQuery{Path: "/account/difficulty", Data: []byte(id)})
+			Expect(res.Code).To(Equal(types.CodeType_OK))
+			result := &state.Difficulty{}
+			Expect(json.Unmarshal(res.Value, result)).To(Succeed())
+			return result
+		}
+		// alice created a secret already
+		Expect(difficulty("alice").ProofOfWorkCost).To(Equal(testProofOfWorkCost + 1))
+		Expect(difficulty("bob").ProofOfWorkCost).To(Equal(testProofOfWorkCost))
+
+		tx := transaction.New(transaction.ReputationGive, &transaction.ReputationGiveData{
+			From:  "alice",
+			To:    "bob",
+			Value: 1,
+		})
+		tx.Sequence = 2
+		Expect(tx.Sign(aliceKey)).To(Succeed())
+		for tx.VerifyProofOfWork(testProofOfWorkCost) != nil || tx.VerifyProofOfWork(testProofOfWorkCost+1) == nil {
+			tx.Nonce++
+		}
+		Expect(deliver(app, tx).IsErr()).To(BeTrue())
+		_, err := tx.ProofOfWork(context.Background(), testProofOfWorkCost+1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deliver(app, tx).IsOK()).To(BeTrue())
+		Expect(difficulty("alice").ProofOfWorkCost).To(Equal(testProofOfWorkCost + 2))
+		Expect(difficulty("alice").WindowTxs).To(Equal(2))
+
+		// the budget is renewed in the next window
+		app.BeginBlock(types.RequestBeginBlock{Header: &types.Header{Height: state.DefaultBudgetBlocks}})
+		Expect(difficulty("alice").ProofOfWorkCost).To(Equal(testProofOfWorkCost))
+	})
+})
@@ -55,7 +55,8 @@ type PersistentApplication struct {
 	logger log.Logger
 }
 
-func NewPersistentApplication(dbDir string) *PersistentApplication {
+// NewPersistentApplication constructs an application which is stored in dbDir, genesis is stored with the first block
+func NewPersistentApplication(dbDir string, genesis *state.Genesis) *PersistentApplication {
 	db := dbm.NewDB("dummy", "leveldb", dbDir)
 	lastBlock := LoadLastBlock(db)
 
@@ -64,8 +65,10 @@ func NewPersistentApplication(dbDir string) *PersistentApplication {
 
 	// log.Notice("Loaded state", "block", lastBlock.Height, "root", stateTree.Hash())
 
+	appState := state.NewStateFromTree(stateTree)
+	appState.Genesis = genesis
 	return &PersistentApplication{
-		app:    &Application{state: state.NewStateFromTree(stateTree)},
+		app:    &Application{state: appState},
 		db:     db,
 		logger: log.NewNopLogger(),
 	}
@@ -139,6 +142,7 @@ func (app *PersistentApplication) InitChain(req types.RequestInitChain) {
 func (app *PersistentApplication) BeginBlock(req types.RequestBeginBlock) {
 	// update latest block info
 	app.blockHeader = req.GetHeader()
+	app.app.BeginBlock(req)
 
 	// reset valset changes
 	app.changes = make([]*types.Validator, 0)
@@ -36,22 +36,42 @@ func checkAccountAddTransaction(tx *transaction.Transaction, state *state.State)
 		return err
 	}
 	tx.Data = data
+	if data.Account == nil {
+		return errors.New("no account supplied")
+	}
 	if state.HasAccount(data.Account.ID) {
 		return errors.New("account exists")
 	}
-	if _, err := crypto.NewFromStrings(data.Account.PubKey, ""); err != nil {
+	if err := state.ValidateGuardians(data.Account); err != nil {
+		return err
+	}
+	k, err := crypto.NewFromStrings(data.Account.PubKey, "")
+	if err != nil {
 		return err
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified, sender doesn't own the supplied key: " + err.Error())
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.Account.ID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverAccountAddTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.AccountAddData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkAccountAddTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.AccountAddData)
+	// a registered id continues with the sequence it had, so old transactions can't be replayed
+	sequence, err := state.DeletedSequence(data.Account.ID)
+	if err != nil {
 		return err
 	}
+	data.Account.Sequence = sequence
+	data.Account.Recovery = nil
+	data.Account.Reputation = nil
+	data.Account.Window = 0
+	data.Account.WindowTxs = 0
 	return state.AddAccount(data.Account)
 }
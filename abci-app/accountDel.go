@@ -45,15 +45,27 @@ func checkAccountDelTransaction(tx *transaction.Transaction, state *state.State)
 	if err = tx.Verify(k); err != nil {
 		return errors.New("tx can't be verified: " + err.Error())
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := checkSequence(tx, state, data.ID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.ID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverAccountDelTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.AccountDelData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkAccountDelTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.AccountDelData)
+	if state.HasGroup(data.ID) {
+		if err := state.DeleteGroup(data.ID); err != nil {
+			return err
+		}
+	}
+	// the sequence of the deletion itself must not be reusable either
+	if err := state.IncrementSequence(data.ID); err != nil {
 		return err
 	}
 	return state.DeleteAccount(data.ID)
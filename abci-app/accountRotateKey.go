@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkAccountRotateKeyTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.AccountRotateKeyData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	acc, err := state.GetAccount(data.ID)
+	if err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.ID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.ID); err != nil {
+		return err
+	}
+	newKey, err := crypto.NewFromStrings(data.PubKey, "")
+	if err != nil {
+		return err
+	}
+	hash := data.ApprovalHash(tx.Sequence)
+	if err = newKey.Verify(hash, data.Proof); err != nil {
+		return errors.New("sender doesn't own the new key: " + err.Error())
+	}
+	if err := checkGuardianApprovals(acc, data.Approvals, hash, state); err != nil {
+		return err
+	}
+	if err := checkRewrappedKeys(data.ID, data.Shares, data.GroupKeys, data.MemberKeys, "", state); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.ID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverAccountRotateKeyTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkAccountRotateKeyTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.AccountRotateKeyData)
+	if err := installKey(data.ID, data.PubKey, data.Shares, data.GroupKeys, data.MemberKeys, state); err != nil {
+		return err
+	}
+	acc, err := state.GetAccount(data.ID)
+	if err != nil {
+		return err
+	}
+	// the account isn't lost, so a pending recovery is obsolete
+	acc.Recovery = nil
+	if err := state.SetAccount(acc); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.ID)
+}
+
+// checkGuardianApprovals checks that enough guardians of acc signed hash
+func checkGuardianApprovals(acc *state.Account, approvals map[string]string, hash []byte, state *state.State) error {
+	count := 0
+	for guardian, signature := range approvals {
+		if !acc.IsGuardian(guardian) {
+			return errors.New(guardian + " is not a guardian of this account")
+		}
+		k, err := state.GetAccountPubKey(guardian)
+		if err != nil {
+			return errors.New("pubkey of guardian can't be loaded: " + err.Error())
+		}
+		if err = k.Verify(hash, signature); err != nil {
+			return errors.New("approval of " + guardian + " can't be verified: " + err.Error())
+		}
+		count++
+	}
+	if count < acc.GuardianThreshold {
+		return errors.New("not enough guardian approvals")
+	}
+	return nil
+}
+
+// checkRewrappedKeys checks that a key rotation of account id carries a new key for every share and group membership
+// of the account, and for every member if the account is a group. removed is a member which doesn't get the new key.
+func checkRewrappedKeys(id string, shares, groupKeys, memberKeys map[string]string, removed string, state *state.State) error {
+	shared, err := state.GetSharedSecrets(id)
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]bool)
+	for _, sid := range append(shared.Owned, shared.Readable...) {
+		expected[sid] = true
+	}
+	if !reflect.DeepEqual(expected, keys(shares)) {
+		return errors.New("the rotation must re-encrypt exactly the shares of the account")
+	}
+	groups, err := state.GroupsOf(id)
+	if err != nil {
+		return err
+	}
+	expected = make(map[string]bool)
+	for _, group := range groups {
+		expected[group.ID] = true
+	}
+	if !reflect.DeepEqual(expected, keys(groupKeys)) {
+		return errors.New("the rotation must re-encrypt exactly the group keys of the account")
+	}
+	expected = make(map[string]bool)
+	if state.HasGroup(id) {
+		group, err := state.GetGroup(id)
+		if err != nil {
+			return err
+		}
+		expected = keys(group.Keys)
+		delete(expected, removed)
+	}
+	if !reflect.DeepEqual(expected, keys(memberKeys)) {
+		return errors.New("the rotation must encrypt the new key for exactly the members of the group")
+	}
+	return nil
+}
+
+// installKey replaces the public key of account id and stores the keys which were re-encrypted for it
+func installKey(id, pubKey string, shares, groupKeys, memberKeys map[string]string, state *state.State) error {
+	// the state must be written in the same order on every node
+	for _, sid := range sortedKeys(shares) {
+		secret, err := state.GetSecret(sid)
+		if err != nil {
+			return err
+		}
+		secret.Shares[id] = shares[sid]
+		if err = state.SetSecret(secret); err != nil {
+			return err
+		}
+	}
+	for _, gid := range sortedKeys(groupKeys) {
+		group, err := state.GetGroup(gid)
+		if err != nil {
+			return err
+		}
+		group.Keys[id] = groupKeys[gid]
+		if err = state.SetGroup(group); err != nil {
+			return err
+		}
+	}
+	if state.HasGroup(id) {
+		group, err := state.GetGroup(id)
+		if err != nil {
+			return err
+		}
+		group.Keys = memberKeys
+		if err = state.SetGroup(group); err != nil {
+			return err
+		}
+	}
+	acc, err := state.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	acc.PubKey = pubKey
+	return state.SetAccount(acc)
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkGroupCreateTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.GroupCreateData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	if data.Group == nil || data.Group.ID == "" {
+		return errors.New("no group supplied")
+	}
+	if state.HasGroup(data.Group.ID) {
+		return errors.New("group exists")
+	}
+	if state.HasAccount(data.Group.ID) {
+		// an existing account can only be turned into a group with its own key, which the proof below verifies
+		pubKey, err := state.GetAccountPubKey(data.Group.ID)
+		if err != nil {
+			return err
+		}
+		if pubKey.GetPubString() != data.PubKey {
+			return errors.New("account exists")
+		}
+	}
+	groupKey, err := crypto.NewFromStrings(data.PubKey, "")
+	if err != nil {
+		return err
+	}
+	hash, err := data.ProofHash(tx)
+	if err != nil {
+		return err
+	}
+	if err = groupKey.Verify(hash, data.Proof); err != nil {
+		return errors.New("sender doesn't own the group key: " + err.Error())
+	}
+	if !data.Group.IsAdmin(data.SenderID) {
+		return errors.New("sender is not admin of this group")
+	}
+	for member := range data.Group.Keys {
+		if !state.HasAccount(member) {
+			return errors.New("member " + member + " doesn't exist")
+		}
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverGroupCreateTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkGroupCreateTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.GroupCreateData)
+	if err := state.AddGroup(data.Group, data.PubKey); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkGroupMemberAddTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.GroupMemberAddData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	group, err := state.GetGroup(data.ID)
+	if err != nil {
+		return err
+	}
+	if !group.IsAdmin(data.SenderID) {
+		return errors.New("sender is not admin of this group")
+	}
+	if group.IsMember(data.AccountID) {
+		return errors.New("account is already member of this group")
+	}
+	if !state.HasAccount(data.AccountID) {
+		return errors.New("new member doesn't exist")
+	}
+	if data.Key == "" {
+		return errors.New("no key supplied")
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverGroupMemberAddTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkGroupMemberAddTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.GroupMemberAddData)
+	group, err := state.GetGroup(data.ID)
+	if err != nil {
+		return err
+	}
+	group.Keys[data.AccountID] = data.Key
+	if data.IsAdmin {
+		group.Admins[data.AccountID] = true
+	}
+	if err := state.SetGroup(group); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
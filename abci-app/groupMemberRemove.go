@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkGroupMemberRemoveTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.GroupMemberRemoveData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	group, err := state.GetGroup(data.ID)
+	if err != nil {
+		return err
+	}
+	if !group.IsAdmin(data.SenderID) {
+		return errors.New("sender is not admin of this group")
+	}
+	// the sender generates the new group key, so the removed member must not be the sender
+	if data.SenderID == data.AccountID {
+		return errors.New("members can't remove themselves, ask another admin")
+	}
+	if !group.IsMember(data.AccountID) {
+		return errors.New("account is not member of this group")
+	}
+	if group.IsAdmin(data.AccountID) && group.CountAdmins() == 1 {
+		return errors.New("can not remove the last admin of this group")
+	}
+	newKey, err := crypto.NewFromStrings(data.PubKey, "")
+	if err != nil {
+		return err
+	}
+	hash, err := data.ProofHash(tx)
+	if err != nil {
+		return err
+	}
+	if err = newKey.Verify(hash, data.Proof); err != nil {
+		return errors.New("sender doesn't own the new group key: " + err.Error())
+	}
+	if err := checkRewrappedKeys(data.ID, data.Shares, data.GroupKeys, data.MemberKeys, data.AccountID, state); err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverGroupMemberRemoveTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkGroupMemberRemoveTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.GroupMemberRemoveData)
+	// the group gets a new key pair, the removed member doesn't get the new private key
+	if err := installKey(data.ID, data.PubKey, data.Shares, data.GroupKeys, data.MemberKeys, state); err != nil {
+		return err
+	}
+	group, err := state.GetGroup(data.ID)
+	if err != nil {
+		return err
+	}
+	delete(group.Admins, data.AccountID)
+	if err := state.SetGroup(group); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkParamsSetTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.ParamsSetData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	if data.Params == nil {
+		return errors.New("no params supplied")
+	}
+	current, err := state.GetParams()
+	if err != nil {
+		return err
+	}
+	if len(current.Admins) == 0 {
+		return errors.New("the params of this chain can't be changed, it has no admins")
+	}
+	if !current.IsAdmin(data.SenderID) {
+		return errors.New("only admins can change the params")
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if data.Params.Version != current.Version+1 {
+		return fmt.Errorf("bad params version: expected %v, got %v", current.Version+1, data.Params.Version)
+	}
+	if err = data.Params.Validate(); err != nil {
+		return err
+	}
+	for _, admin := range data.Params.Admins {
+		if !state.HasAccount(admin) {
+			return errors.New("admin " + admin + " doesn't exist")
+		}
+	}
+	if err := checkAdminApprovals(current, data, state); err != nil {
+		return err
+	}
+	// the new cost applies to the following transactions
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverParamsSetTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkParamsSetTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.ParamsSetData)
+	if err := state.SetParams(data.Params); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
+
+// checkAdminApprovals checks that enough admins of the current params approved the change, the sender included
+func checkAdminApprovals(current *state.Params, data *transaction.ParamsSetData, state *state.State) error {
+	hash := data.ApprovalHash()
+	count := 1
+	for admin, signature := range data.Approvals {
+		if admin == data.SenderID {
+			continue
+		}
+		if !current.IsAdmin(admin) {
+			return errors.New(admin + " is not an admin")
+		}
+		k, err := state.GetAccountPubKey(admin)
+		if err != nil {
+			return errors.New("pubkey of admin can't be loaded: " + err.Error())
+		}
+		if err = k.Verify(hash, signature); err != nil {
+			return errors.New("approval of " + admin + " can't be verified: " + err.Error())
+		}
+		count++
+	}
+	if count < current.GetAdminThreshold() {
+		return errors.New("not enough admin approvals")
+	}
+	return nil
+}
@@ -41,6 +41,9 @@ func checkReputationGiveTransaction(tx *transaction.Transaction, state *state.St
 	if !state.HasAccount(data.To) {
 		return errors.New("reject give-rep because id doesnt exist: " + data.From)
 	}
+	if data.From == data.To {
+		return errors.New("reject give-rep because accounts can't rate themselves")
+	}
 	if data.Value < -3 || data.Value > 3 {
 		return errors.New("reject give-rep because bad value")
 	}
@@ -51,17 +54,20 @@ func checkReputationGiveTransaction(tx *transaction.Transaction, state *state.St
 	if err = tx.Verify(k); err != nil {
 		return errors.New("reject give-rep because signature cant be verified")
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := checkSequence(tx, state, data.From); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.From)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverReputationGiveTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.ReputationGiveData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkReputationGiveTransaction(tx, state); err != nil {
 		return err
 	}
+	data := tx.Data.(*transaction.ReputationGiveData)
 	acc, err := state.GetAccount(data.To)
 	if err != nil {
 		return err
@@ -74,5 +80,5 @@ func deliverReputationGiveTransaction(tx *transaction.Transaction, state *state.
 	if err != nil {
 		return err
 	}
-	return nil
+	return state.IncrementSequence(data.From)
 }
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+// requireRole returns an error if account hasn't at least the given role on secret
+func requireRole(secret *state.Secret, account string, role state.Role) error {
+	if _, ok := secret.Shares[account]; !ok {
+		return errors.New("sender has no share on this secret")
+	}
+	if !secret.RoleOf(account).Includes(role) {
+		return fmt.Errorf("sender needs the %v role on this secret", role)
+	}
+	return nil
+}
+
+// requireWriter returns an error if account can't write secret
+func requireWriter(secret *state.Secret, account string) error {
+	return requireRole(secret, account, state.RoleWriter)
+}
+
+// requireAdmin returns an error if account isn't admin of secret
+func requireAdmin(secret *state.Secret, account string) error {
+	return requireRole(secret, account, state.RoleAdmin)
+}
+
+// checkUpdateRoles checks that the sender of a secret-update is allowed to replace old with secret
+func checkUpdateRoles(old, secret *state.Secret, sender string) error {
+	if err := requireRole(old, sender, state.RoleWriter); err != nil {
+		return err
+	}
+	if err := secret.ValidateRoles(); err != nil {
+		return err
+	}
+	if old.RoleOf(sender) == state.RoleAdmin {
+		if secret.CountAdmins() == 0 {
+			return errors.New("can not remove the last admin of this secret")
+		}
+		return nil
+	}
+	// the encrypted data keys must stay the same as well, otherwise a writer could lock others out
+	if !reflect.DeepEqual(old.Shares, secret.Shares) {
+		return errors.New("only admins can change the shares of a secret")
+	}
+	for account := range old.Shares {
+		if old.RoleOf(account) != secret.RoleOf(account) {
+			return errors.New("only admins can change the roles of a secret")
+		}
+	}
+	if secret.RequiredApprovals != old.RequiredApprovals || secret.Threshold != old.Threshold {
+		return errors.New("only admins can change the approval policy of a secret")
+	}
+	return nil
+}
+
+// checkShareRole checks that the sender of a secret-share is allowed to grant the requested role
+func checkShareRole(secret *state.Secret, data *transaction.SecretShareData) error {
+	if err := requireRole(secret, data.SenderID, state.RoleSharer); err != nil {
+		return err
+	}
+	granted := data.GrantedRole()
+	if !granted.IsValid() {
+		return fmt.Errorf("unknown role %v", granted)
+	}
+	_, hasShare := secret.Shares[data.AccountID]
+	if secret.RoleOf(data.SenderID) != state.RoleAdmin {
+		if granted != state.RoleReader {
+			return errors.New("only admins can grant roles other than reader")
+		}
+		if hasShare {
+			return errors.New("share receiver already has a share on this secret")
+		}
+		return nil
+	}
+	if granted != state.RoleAdmin && isLastAdmin(secret, data.AccountID) {
+		return errors.New("can not remove the last admin of this secret")
+	}
+	return nil
+}
+
+// withRole returns a copy of secret in which account has the given role, an empty role removes its share
+func withRole(secret *state.Secret, account string, role state.Role) *state.Secret {
+	changed := *secret
+	changed.Shares = make(map[string]string)
+	changed.Roles = make(map[string]state.Role)
+	for other, key := range secret.Shares {
+		changed.Shares[other] = key
+		changed.Roles[other] = secret.RoleOf(other)
+	}
+	changed.Owners = nil
+	if role == "" {
+		delete(changed.Shares, account)
+		delete(changed.Roles, account)
+	} else {
+		changed.Shares[account] = secret.Shares[account]
+		changed.Roles[account] = role
+	}
+	return &changed
+}
+
+// checkApprovalPolicy returns an error if changing old into secret must be proposed, secret is nil for deletions
+func checkApprovalPolicy(old, secret *state.Secret) error {
+	if old.NeedsApproval(secret) {
+		return fmt.Errorf("this change needs the approval of %v admins, propose it", old.ApprovalsFor(secret))
+	}
+	if secret != nil {
+		return secret.ValidateApprovals()
+	}
+	return nil
+}
+
+// isLastAdmin returns true if account is the only admin of secret
+func isLastAdmin(secret *state.Secret, account string) bool {
+	return secret.RoleOf(account) == state.RoleAdmin && secret.CountAdmins() == 1
+}
+
+// keys returns the keys of a share map, the encrypted keys themselves may change on updates
+func keys(shares map[string]string) map[string]bool {
+	res := make(map[string]bool)
+	for account := range shares {
+		res[account] = true
+	}
+	return res
+}
+
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for key := range m {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -35,25 +35,50 @@ func checkSecretAddTransaction(tx *transaction.Transaction, state *state.State)
 		return err
 	}
 	tx.Data = data
+	if data.Secret == nil {
+		return errors.New("no secret supplied")
+	}
 	if state.HasSecret(data.Secret.ID) {
 		return errors.New("secret exists")
 	}
 	if len(data.Secret.Shares) == 0 {
 		return errors.New("no shares supplied")
 	}
-	if len(data.Secret.Owners) == 0 {
-		return errors.New("no owners supplied")
+	if err := data.Secret.ValidateRoles(); err != nil {
+		return err
+	}
+	if err := data.Secret.ValidateThreshold(); err != nil {
+		return err
+	}
+	if err := data.Secret.ValidateApprovals(); err != nil {
+		return err
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := requireAdmin(data.Secret, data.SenderID); err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverSecretAddTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.SecretAddData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkSecretAddTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretAddData)
+	if err := state.AddSecret(data.Secret, data.SenderID); err != nil {
 		return err
 	}
-	return state.AddSecret(data.Secret)
+	return state.IncrementSequence(data.SenderID)
 }
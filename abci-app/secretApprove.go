@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkSecretApproveTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.SecretApproveData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	secret, err := state.GetSecret(data.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(secret, data.SenderID); err != nil {
+		return err
+	}
+	proposal, err := state.GetProposal(data.ID, data.ProposalID)
+	if err != nil {
+		return err
+	}
+	if proposal.Approvals[data.SenderID] {
+		return errors.New("proposal is already approved by sender")
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverSecretApproveTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkSecretApproveTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretApproveData)
+	proposal, err := state.GetProposal(data.ID, data.ProposalID)
+	if err != nil {
+		return err
+	}
+	proposal.Approvals[data.SenderID] = true
+	if err := executeProposal(state, proposal); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
@@ -42,11 +42,11 @@ func checkSecretDelTransaction(tx *transaction.Transaction, state *state.State)
 	if err != nil {
 		return err
 	}
-	if _, ok := secret.Shares[data.SenderID]; !ok {
-		return errors.New("sender has no share on this secret")
+	if err := requireAdmin(secret, data.SenderID); err != nil {
+		return err
 	}
-	if _, ok := secret.Owners[data.SenderID]; !ok {
-		return errors.New("sender is not owner of this secret")
+	if err := checkApprovalPolicy(secret, nil); err != nil {
+		return err
 	}
 	k, err := state.GetAccountPubKey(data.SenderID)
 	if err != nil {
@@ -55,16 +55,22 @@ func checkSecretDelTransaction(tx *transaction.Transaction, state *state.State)
 	if err = tx.Verify(k); err != nil {
 		return errors.New("tx can't be verified: " + err.Error())
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverSecretDelTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.SecretDelData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkSecretDelTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretDelData)
+	if err := state.DeleteSecret(data.ID); err != nil {
 		return err
 	}
-	return state.DeleteSecret(data.ID)
+	return state.IncrementSequence(data.SenderID)
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkSecretProposeTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.SecretProposeData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	secret, err := state.GetSecret(data.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(secret, data.SenderID); err != nil {
+		return err
+	}
+	if err := checkProposal(secret, newProposal(data, tx.Sequence)); err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverSecretProposeTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkSecretProposeTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretProposeData)
+	proposal := newProposal(data, tx.Sequence)
+	if err := state.AddProposal(proposal); err != nil {
+		return err
+	}
+	if err := executeProposal(state, proposal); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
+
+func newProposal(data *transaction.SecretProposeData, sequence uint64) *state.Proposal {
+	return &state.Proposal{
+		ID:        state.NewProposalID(data.SenderID, sequence),
+		SecretID:  data.ID,
+		Proposer:  data.SenderID,
+		Action:    data.Action,
+		AccountID: data.AccountID,
+		Secret:    data.Secret,
+		Version:   data.Version,
+		Approvals: map[string]bool{data.SenderID: true},
+	}
+}
+
+// checkProposal checks that the action of a proposal can be applied to secret
+func checkProposal(secret *state.Secret, proposal *state.Proposal) error {
+	if proposal.Version != secret.Version {
+		return errors.New("the secret changed since the proposal was made")
+	}
+	switch proposal.Action {
+	case state.ProposalDelete:
+		return nil
+	case state.ProposalUnshare:
+		if err := checkUnshare(secret, proposal.AccountID, proposal.Secret); err != nil {
+			return err
+		}
+		return withRole(secret, proposal.AccountID, "").ValidateApprovals()
+	case state.ProposalUpdate:
+		if proposal.Secret == nil || proposal.Secret.ID != secret.ID {
+			return errors.New("no matching secret supplied")
+		}
+		if err := checkUpdateRoles(secret, proposal.Secret, proposal.Proposer); err != nil {
+			return err
+		}
+		if err := checkThreshold(secret, proposal.Secret); err != nil {
+			return err
+		}
+		return proposal.Secret.ValidateApprovals()
+	}
+	return errors.New("unknown proposal action")
+}
+
+// executeProposal applies the action of a proposal once enough admins approved it
+func executeProposal(st *state.State, proposal *state.Proposal) error {
+	secret, err := st.GetSecret(proposal.SecretID)
+	if err != nil {
+		return err
+	}
+	approvals := 0
+	for account := range proposal.Approvals {
+		if secret.RoleOf(account) == state.RoleAdmin {
+			approvals++
+		}
+	}
+	if approvals < secret.ApprovalsFor(proposal.Secret) {
+		return st.SetProposal(proposal)
+	}
+	if err = checkProposal(secret, proposal); err != nil {
+		return errors.New("proposal can't be executed anymore: " + err.Error())
+	}
+	if err = st.DeleteProposal(proposal.SecretID, proposal.ID); err != nil {
+		return err
+	}
+	switch proposal.Action {
+	case state.ProposalDelete:
+		return st.DeleteSecret(proposal.SecretID)
+	case state.ProposalUnshare:
+		return st.RekeySecret(proposal.Secret, proposal.Proposer)
+	default:
+		return st.UpdateSecret(proposal.Secret, proposal.Proposer)
+	}
+}
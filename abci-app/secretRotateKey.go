@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkSecretRotateKeyTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.SecretRotateKeyData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	if data.Secret == nil {
+		return errors.New("no secret supplied")
+	}
+	secret, err := state.GetSecret(data.Secret.ID)
+	if err != nil {
+		return err
+	}
+	// a rekey replaces the encrypted data keys of all accounts
+	if err := requireAdmin(secret, data.SenderID); err != nil {
+		return err
+	}
+	if err := checkRekeyedSecret(secret, data.Secret, ""); err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverSecretRotateKeyTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkSecretRotateKeyTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretRotateKeyData)
+	if err := state.RekeySecret(data.Secret, data.SenderID); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
+
+// checkRekeyedSecret checks that secret has the same shares and roles as old, except for the removed account
+func checkRekeyedSecret(old, secret *state.Secret, removed string) error {
+	if secret.ID != old.ID {
+		return errors.New("secret id doesn't match")
+	}
+	expected := keys(old.Shares)
+	delete(expected, removed)
+	if !reflect.DeepEqual(expected, keys(secret.Shares)) {
+		return errors.New("the shares of the secret must not change when rotating its key")
+	}
+	if err := secret.ValidateRoles(); err != nil {
+		return err
+	}
+	if err := checkThreshold(old, secret); err != nil {
+		return err
+	}
+	if secret.RequiredApprovals != old.RequiredApprovals {
+		return errors.New("the required approvals of a secret can't be changed when rotating its key")
+	}
+	for account := range secret.Shares {
+		if secret.RoleOf(account) != old.RoleOf(account) {
+			return errors.New("the roles of the secret must not change when rotating its key")
+		}
+	}
+	return nil
+}
+
+// checkThreshold checks that secret keeps the threshold of old and that it can still be reached
+func checkThreshold(old, secret *state.Secret) error {
+	if secret.Threshold != old.Threshold {
+		return errors.New("the threshold of a secret can't be changed")
+	}
+	return secret.ValidateThreshold()
+}
+
+// checkThresholdRekey checks that a new value of a threshold secret comes with a new data key.
+// Members which unlocked the old key could read the new value otherwise.
+func checkThresholdRekey(old, secret *state.Secret) error {
+	if secret.Threshold == 0 || secret.Value == old.Value {
+		return nil
+	}
+	for account, share := range secret.Shares {
+		if old.Shares[account] == share {
+			return errors.New("a new value of a threshold secret needs a new data key, only admins can set it")
+		}
+	}
+	return nil
+}
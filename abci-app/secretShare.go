@@ -39,14 +39,17 @@ func checkSecretShareTransaction(tx *transaction.Transaction, state *state.State
 	if err != nil {
 		return err
 	}
-	if _, ok := secret.Shares[data.SenderID]; !ok {
-		return errors.New("sender has no share on this secret")
+	if secret.Threshold > 0 {
+		return errors.New("the members of a threshold secret can't be changed by sharing it")
 	}
-	if _, ok := secret.Owners[data.SenderID]; !ok {
-		return errors.New("sender is not owner of this secret")
+	if err := checkShareRole(secret, data); err != nil {
+		return err
+	}
+	if err := checkApprovalPolicy(secret, withRole(secret, data.AccountID, data.GrantedRole())); err != nil {
+		return err
 	}
-	if _, ok := secret.Shares[data.AccountID]; ok {
-		return errors.New("share receiver already has a share on this secret")
+	if !state.HasAccount(data.AccountID) {
+		return errors.New("share receiver doesn't exist")
 	}
 	k, err := state.GetAccountPubKey(data.SenderID)
 	if err != nil {
@@ -55,21 +58,28 @@ func checkSecretShareTransaction(tx *transaction.Transaction, state *state.State
 	if err = tx.Verify(k); err != nil {
 		return errors.New("tx can't be verified: " + err.Error())
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverSecretShareTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.SecretShareData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkSecretShareTransaction(tx, state); err != nil {
 		return err
 	}
+	data := tx.Data.(*transaction.SecretShareData)
 	secret, err := state.GetSecret(data.ID)
 	if err != nil {
 		return err
 	}
 	secret.Shares[data.AccountID] = data.Key
-	return state.SetSecret(secret)
+	secret.Roles[data.AccountID] = data.GrantedRole()
+	if err := state.SetSecret(secret); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
 }
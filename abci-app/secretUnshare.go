@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package app
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+func checkSecretUnshareTransaction(tx *transaction.Transaction, state *state.State) error {
+	data := &transaction.SecretUnshareData{}
+	if err := mapstructure.Decode(tx.Data, data); err != nil {
+		return err
+	}
+	tx.Data = data
+	secret, err := state.GetSecret(data.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(secret, data.SenderID); err != nil {
+		return err
+	}
+	if err := checkUnshare(secret, data.AccountID, data.Secret); err != nil {
+		return err
+	}
+	if err := checkApprovalPolicy(secret, withRole(secret, data.AccountID, "")); err != nil {
+		return err
+	}
+	k, err := state.GetAccountPubKey(data.SenderID)
+	if err != nil {
+		return errors.New("pubkey can't be loaded: " + err.Error())
+	}
+	if err = tx.Verify(k); err != nil {
+		return errors.New("tx can't be verified: " + err.Error())
+	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deliverSecretUnshareTransaction(tx *transaction.Transaction, state *state.State) error {
+	if err := checkSecretUnshareTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretUnshareData)
+	if err := state.RekeySecret(data.Secret, data.SenderID); err != nil {
+		return err
+	}
+	return state.IncrementSequence(data.SenderID)
+}
+
+// checkUnshare checks that the share of account can be removed from secret.
+// rekeyed is the remaining secret encrypted with a new data key, so the account can't read future values.
+func checkUnshare(secret *state.Secret, account string, rekeyed *state.Secret) error {
+	if _, ok := secret.Shares[account]; !ok {
+		return errors.New("account has no share on this secret")
+	}
+	if isLastAdmin(secret, account) {
+		return errors.New("can not remove the last admin of this secret")
+	}
+	if rekeyed == nil {
+		return errors.New("no secret with a new data key supplied")
+	}
+	if err := checkRekeyedSecret(secret, rekeyed, account); err != nil {
+		return err
+	}
+	for other, key := range rekeyed.Shares {
+		if secret.Shares[other] == key {
+			return errors.New("the data key of " + other + " wasn't replaced")
+		}
+	}
+	return nil
+}
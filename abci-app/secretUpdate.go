@@ -35,6 +35,9 @@ func checkSecretUpdateTransaction(tx *transaction.Transaction, state *state.Stat
 		return err
 	}
 	tx.Data = data
+	if data.Secret == nil {
+		return errors.New("no secret supplied")
+	}
 	k, err := state.GetAccountPubKey(data.SenderID)
 	if err != nil {
 		return errors.New("pubkey can't be loaded: " + err.Error())
@@ -42,26 +45,38 @@ func checkSecretUpdateTransaction(tx *transaction.Transaction, state *state.Stat
 	if err = tx.Verify(k); err != nil {
 		return errors.New("tx can't be verified: " + err.Error())
 	}
+	if err := checkSequence(tx, state, data.SenderID); err != nil {
+		return err
+	}
 	secret, err := state.GetSecret(data.Secret.ID)
 	if err != nil {
 		return err
 	}
-	if _, ok := secret.Shares[data.SenderID]; !ok {
-		return errors.New("sender has no share on this secret")
+	if err := checkUpdateRoles(secret, data.Secret, data.SenderID); err != nil {
+		return err
+	}
+	if err := checkThreshold(secret, data.Secret); err != nil {
+		return err
+	}
+	if err := checkThresholdRekey(secret, data.Secret); err != nil {
+		return err
 	}
-	if _, ok := secret.Owners[data.SenderID]; !ok {
-		return errors.New("sender is not owner of this secret")
+	if err := checkApprovalPolicy(secret, data.Secret); err != nil {
+		return err
 	}
-	if err := tx.VerifyProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	if err := tx.VerifyProofOfWork(state.ProofOfWorkCostFor(data.SenderID)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func deliverSecretUpdateTransaction(tx *transaction.Transaction, state *state.State) error {
-	data := &transaction.SecretUpdateData{}
-	if err := mapstructure.Decode(tx.Data, &data); err != nil {
+	if err := checkSecretUpdateTransaction(tx, state); err != nil {
+		return err
+	}
+	data := tx.Data.(*transaction.SecretUpdateData)
+	if err := state.UpdateSecret(data.Secret, data.SenderID); err != nil {
 		return err
 	}
-	return state.SetSecret(data.Secret)
+	return state.IncrementSequence(data.SenderID)
 }
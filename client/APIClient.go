@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/trusch/passchain/crypto"
 	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
 )
 
 // API is the high level interface for passchain client applications
@@ -15,6 +18,8 @@ type API interface {
 	AccountAPI
 	ReputationAPI
 	SecretAPI
+	GroupAPI
+	ParamsAPI
 }
 
 // AccountAPI describes all account related functions
@@ -23,6 +28,23 @@ type AccountAPI interface {
 	GetAccount(id string) (*state.Account, error)
 	DeleteAccount(id string) error
 	ListAccounts(idPrefix string) ([]*state.Account, error)
+	IterateAccounts(idPrefix string) *AccountIterator
+	RotateAccountKey(newKey *crypto.Key, approvals map[string]string) error
+	ApproveKeyRotation(accountID, pubKey string) (signature string, err error)
+	SetGuardians(guardians []string, threshold int, delay uint64) error
+	RecoverAccount(accountID, pubKey string) error
+	CancelRecovery() error
+	GetDifficulty(id string) (*state.Difficulty, error)
+}
+
+// GroupAPI describes operations on groups
+type GroupAPI interface {
+	CreateGroup(id string) error
+	GetGroup(id string) (*state.Group, error)
+	ListGroups(idPrefix string) ([]*state.Group, error)
+	AddGroupMember(groupID, accountID string, isAdmin bool) error
+	RemoveGroupMember(groupID, accountID string) error
+	MigrateGroup(id string) error
 }
 
 // ReputationAPI describes the reputation related function
@@ -30,54 +52,120 @@ type ReputationAPI interface {
 	GiveReputation(receiver string, value int) error
 }
 
+// ParamsAPI describes the governance of the chain params
+type ParamsAPI interface {
+	GetParams() (*state.Params, error)
+	SetParams(params *state.Params, approvals map[string]string) error
+	ApproveParams(params *state.Params) (signature string, err error)
+}
+
 // SecretAPI describes operations on secrets
 type SecretAPI interface {
-	CreateSecret(sid string, value string) error
+	CreateSecret(sid string, value string, opts *SecretOptions) error
 	GetSecret(sid string) (*state.Secret, error)
 	DeleteSecret(sid string) error
 	ListSecrets(sidPrefix string) ([]*state.Secret, error)
-	ShareSecret(sid, accountID string, ownerRights bool) error
-	UpdateSecret(sid, value string) error
+	IterateSecrets(sidPrefix string) *SecretIterator
+	ListSharedSecrets(sidPrefix string) ([]*state.Secret, error)
+	ShareSecret(sid, accountID string, role state.Role) error
+	UpdateSecret(sid, value string, opts *SecretOptions) error
 	UnshareSecret(sid, accountID string) error
+	RotateSecretKey(sid string) error
+	MigrateSecret(sid string) error
+	GetSecretHistory(sid string) ([]*state.SecretVersion, error)
+	GetSecretVersion(sid string, version uint64) (*state.Secret, error)
+	ListDueSecrets() ([]*state.Secret, error)
+	RequestUnlock(sid string) error
+	ListUnlockRequests(sid string) ([]*state.UnlockRequest, error)
+	ContributeUnlock(sid, requester string) error
+	CloseUnlock(sid string) error
+	ListProposals(sid string) ([]*state.Proposal, error)
+	ApproveProposal(sid, proposalID string) error
+}
+
+// ErrProposalCreated is returned when an operation needs the approval of other admins of the secret.
+// The operation is executed once enough admins approved the created proposal.
+var ErrProposalCreated = errors.New("the operation needs the approval of other admins, a proposal has been created")
+
+// ErrSecretLocked is returned when a threshold secret hasn't got enough key shares to be decrypted
+var ErrSecretLocked = errors.New("secret is locked, request an unlock and ask the other members to contribute their key shares")
+
+// SecretOptions holds the optional attributes of a secret, attributes which are nil are kept on updates
+type SecretOptions struct {
+	Metadata *state.SecretMetadata
+	// ExpiresAt is a unix time, 0 means never
+	ExpiresAt *uint64
+	// RotateEvery is a number of seconds, 0 means never
+	RotateEvery *uint64
+	// Threshold is the number of members needed to unlock a new secret, 0 means no threshold
+	Threshold int
+	// Members are the accounts which get a key share of a new threshold secret, besides us
+	Members []string
+	// RequiredApprovals is the number of admins which must approve destructive operations on a new secret
+	RequiredApprovals int
+}
+
+// apply sets the attributes which are set in opts
+func (opts *SecretOptions) apply(secret *state.Secret) {
+	if opts.Metadata != nil {
+		secret.Metadata = opts.Metadata
+	}
+	if opts.ExpiresAt != nil {
+		secret.ExpiresAt = *opts.ExpiresAt
+	}
+	if opts.RotateEvery != nil {
+		secret.RotateEvery = *opts.RotateEvery
+	}
 }
 
 // NewAPI constructs a new API instances based on an http transport
 func NewAPI(endpoint string, key *crypto.Key, account string) API {
-	base := NewHTTPClient(endpoint, key, account)
-	return &apiClient{endpoint, base}
+	return NewAPIFromClient(NewHTTPClient(endpoint, key, account))
+}
+
+// NewAPIFromClient constructs a new API instance based on a client, e.g. one with a local transport
+func NewAPIFromClient(base *BaseClient) API {
+	return &apiClient{base}
 }
 
 type apiClient struct {
-	endpoint string
-	base     *BaseClient
+	base *BaseClient
 }
 
+// As returns an API which acts as the given group account
 func (api *apiClient) As(accountID string) (API, error) {
 	asAccount, err := api.GetAccount(accountID)
 	if err != nil {
 		return nil, fmt.Errorf("cannot find account: %v", err)
 	}
-	privkeySecret, err := api.GetSecret(accountID)
+	privKey, err := api.groupKey(accountID)
 	if err != nil {
-		return nil, fmt.Errorf("cannot find shared account secret: %v", err)
-	}
-	encryptedAESKey, ok := privkeySecret.Shares[api.base.AccountID]
-	if !ok {
-		return nil, errors.New("no share for us on this secret")
+		return nil, err
 	}
-	aesKey, err := api.base.Key.DecryptString(encryptedAESKey)
+	key, err := crypto.NewFromStrings(asAccount.PubKey, privKey)
 	if err != nil {
 		return nil, err
 	}
-	err = privkeySecret.Decrypt(aesKey)
+	base := NewClient(api.base.tm, key, accountID)
+	base.Context = api.base.Context
+	return NewAPIFromClient(base), nil
+}
+
+// groupKey returns the private key of a group we are member of
+func (api *apiClient) groupKey(groupID string) (string, error) {
+	group, err := api.base.GetGroup(groupID)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("cannot find group %v: %v", groupID, err)
+	}
+	encryptedKey, ok := group.Keys[api.base.AccountID]
+	if !ok {
+		return "", errors.New("we are not member of this group")
 	}
-	key, err := crypto.NewFromStrings(asAccount.PubKey, string(privkeySecret.Value))
+	privKey, err := api.base.Key.DecryptString(encryptedKey)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return NewAPI(api.endpoint, key, accountID), nil
+	return string(privKey), nil
 }
 
 func (api *apiClient) CreateAccount(id string) (pub, priv string, err error) {
@@ -101,28 +189,163 @@ func (api *apiClient) DeleteAccount(id string) error {
 }
 
 func (api *apiClient) ListAccounts(idPrefix string) ([]*state.Account, error) {
-	return api.base.ListAccounts()
+	accounts := make([]*state.Account, 0)
+	it := api.IterateAccounts(idPrefix)
+	for it.Next() {
+		accounts = append(accounts, it.Account())
+	}
+	return accounts, it.Err()
+}
+
+func (api *apiClient) IterateAccounts(idPrefix string) *AccountIterator {
+	return &AccountIterator{base: api.base, opts: state.ListOptions{Prefix: idPrefix}}
+}
+
+// RotateAccountKey replaces the key of our account with newKey and re-encrypts
+// all our secret shares and group keys, approvals are signatures of our guardians
+func (api *apiClient) RotateAccountKey(newKey *crypto.Key, approvals map[string]string) error {
+	id := api.base.AccountID
+	data := &transaction.AccountRotateKeyData{
+		ID:        id,
+		PubKey:    newKey.GetPubString(),
+		Approvals: approvals,
+	}
+	var err error
+	if data.Shares, data.GroupKeys, data.MemberKeys, err = api.rewrapAll(newKey, ""); err != nil {
+		return err
+	}
+	seq, err := api.base.nextSequence(id)
+	if err != nil {
+		return err
+	}
+	if data.Proof, err = newKey.Sign(data.ApprovalHash(seq)); err != nil {
+		return err
+	}
+	if err = api.base.RotateAccountKey(data, seq); err != nil {
+		return err
+	}
+	api.base.Key = newKey
+	return nil
+}
+
+// rewrapAll re-encrypts our secret shares and group keys for newKey. If we are a group, newKey itself
+// is encrypted for each member except removed.
+func (api *apiClient) rewrapAll(newKey *crypto.Key, removed string) (shares, groupKeys, memberKeys map[string]string, err error) {
+	id := api.base.AccountID
+	shares, groupKeys, memberKeys = make(map[string]string), make(map[string]string), make(map[string]string)
+	shared, err := api.base.GetSharedSecrets(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, sid := range append(shared.Owned, shared.Readable...) {
+		secret, err := api.base.GetSecret(sid)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if shares[sid], err = api.rewrap(secret.Shares[id], newKey); err != nil {
+			return nil, nil, nil, fmt.Errorf("can not re-encrypt share of %v: %v", sid, err)
+		}
+	}
+	groups, err := api.ListGroups("")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, group := range groups {
+		if !group.IsMember(id) {
+			continue
+		}
+		if groupKeys[group.ID], err = api.rewrap(group.Keys[id], newKey); err != nil {
+			return nil, nil, nil, fmt.Errorf("can not re-encrypt key of group %v: %v", group.ID, err)
+		}
+	}
+	if group, err := api.base.GetGroup(id); err == nil {
+		for member := range group.Keys {
+			if member == removed {
+				continue
+			}
+			acc, err := api.GetAccount(member)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("can not find account %v: %v", member, err)
+			}
+			memberKey, err := crypto.NewFromStrings(acc.PubKey, "")
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if memberKeys[member], err = memberKey.EncryptToString([]byte(newKey.GetPrivString())); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	return shares, groupKeys, memberKeys, nil
+}
+
+// rewrap decrypts a key which is encrypted for us and encrypts it for newKey
+func (api *apiClient) rewrap(encrypted string, newKey *crypto.Key) (string, error) {
+	plain, err := api.base.Key.DecryptString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return newKey.EncryptToString(plain)
+}
+
+// ApproveKeyRotation signs the rotation of the key of an account we are guardian of.
+// The approval is only valid until the account sends its next transaction.
+func (api *apiClient) ApproveKeyRotation(accountID, pubKey string) (string, error) {
+	seq, err := api.base.nextSequence(accountID)
+	if err != nil {
+		return "", err
+	}
+	data := &transaction.AccountRotateKeyData{ID: accountID, PubKey: pubKey}
+	return api.base.Key.Sign(data.ApprovalHash(seq))
+}
+
+// SetGuardians sets the accounts which can recover our account if we lose our key.
+// threshold guardians must approve a recovery, which we can cancel within delay seconds.
+func (api *apiClient) SetGuardians(guardians []string, threshold int, delay uint64) error {
+	return api.base.SetGuardians(api.base.AccountID, guardians, threshold, delay)
+}
+
+// RecoverAccount approves the recovery of an account we are guardian of with a new public key
+func (api *apiClient) RecoverAccount(accountID, pubKey string) error {
+	return api.base.RecoverAccount(accountID, pubKey)
+}
+
+// CancelRecovery cancels a pending recovery of our account
+func (api *apiClient) CancelRecovery() error {
+	return api.base.CancelRecovery(api.base.AccountID)
+}
+
+// GetDifficulty returns the proof of work cost the next transaction of an account needs
+func (api *apiClient) GetDifficulty(id string) (*state.Difficulty, error) {
+	return api.base.GetDifficulty(id)
 }
 
-func (api *apiClient) CreateSecret(sid string, value string) error {
+func (api *apiClient) CreateSecret(sid string, value string, opts *SecretOptions) error {
 	s := &state.Secret{
 		ID:     sid,
 		Value:  value,
 		Shares: make(map[string]string),
-		Owners: map[string]bool{
-			api.base.AccountID: true,
+		Roles: map[string]state.Role{
+			api.base.AccountID: state.RoleAdmin,
 		},
 	}
-	aesKey, err := s.Encrypt()
-	if err != nil {
-		return err
+	s.Shares[api.base.AccountID] = ""
+	if opts != nil {
+		opts.apply(s)
+		s.RequiredApprovals = opts.RequiredApprovals
+		if opts.Threshold > 0 {
+			s.Threshold = opts.Threshold
+			for _, member := range opts.Members {
+				s.Shares[member] = ""
+				if member != api.base.AccountID {
+					s.Roles[member] = state.RoleReader
+				}
+			}
+		}
 	}
-	k := api.base.Key
-	encryptedAESKey, err := k.EncryptToString(aesKey)
-	if err != nil {
+	if err := api.rekey(s); err != nil {
 		return err
 	}
-	s.Shares[api.base.AccountID] = encryptedAESKey
 	if err := api.base.AddSecret(s); err != nil {
 		return err
 	}
@@ -134,58 +357,162 @@ func (api *apiClient) GetSecret(sid string) (*state.Secret, error) {
 	if err != nil {
 		return nil, err
 	}
-	encryptedAESKey, ok := secret.Shares[api.base.AccountID]
-	if ok {
-		k := api.base.Key
-		aesKey, err := k.DecryptString(encryptedAESKey)
-		if err != nil {
-			return nil, err
+	return secret, api.decrypt(secret)
+}
+
+// decrypt decrypts a secret if it is shared with us
+func (api *apiClient) decrypt(secret *state.Secret) error {
+	if _, ok := secret.Shares[api.base.AccountID]; !ok {
+		return nil
+	}
+	aesKey, err := api.dataKey(secret)
+	if err != nil {
+		return err
+	}
+	return secret.Decrypt(aesKey)
+}
+
+// dataKey decrypts the data key of a secret. The key of a threshold secret is
+// combined from our key share and the shares contributed to our unlock request.
+func (api *apiClient) dataKey(secret *state.Secret) ([]byte, error) {
+	encryptedKey, ok := secret.Shares[api.base.AccountID]
+	if !ok {
+		return nil, errors.New("no share for us on this secret")
+	}
+	key, err := api.base.Key.DecryptString(encryptedKey)
+	if err != nil || secret.Threshold == 0 {
+		return key, err
+	}
+	parts := [][]byte{key}
+	requests, err := api.base.GetUnlockRequests(secret.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if request.Requester != api.base.AccountID {
+			continue
 		}
-		err = secret.Decrypt(aesKey)
-		if err != nil {
-			return nil, err
+		for member, contribution := range request.Contributions {
+			if _, ok := secret.Shares[member]; !ok {
+				continue
+			}
+			part, err := api.base.Key.DecryptString(contribution)
+			if err != nil {
+				return nil, fmt.Errorf("can not decrypt the key share of %v: %v", member, err)
+			}
+			parts = append(parts, part)
 		}
 	}
-	return secret, nil
+	if len(parts) < secret.Threshold {
+		return nil, ErrSecretLocked
+	}
+	return crypto.CombineShares(parts)
+}
+
+// GetSecretHistory returns all versions of a secret, the values stay encrypted
+func (api *apiClient) GetSecretHistory(sid string) ([]*state.SecretVersion, error) {
+	return api.base.GetSecretHistory(sid)
+}
+
+// GetSecretVersion returns a specific version of a secret and decrypts it if possible
+func (api *apiClient) GetSecretVersion(sid string, version uint64) (*state.Secret, error) {
+	versions, err := api.base.GetSecretHistory(sid)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Version != version {
+			continue
+		}
+		secret := v.Secret
+		keySource := secret
+		if _, ok := secret.Shares[api.base.AccountID]; !ok {
+			// we may have been added after this version was written, so try the current share
+			current, err := api.base.GetSecret(sid)
+			if err != nil {
+				return nil, err
+			}
+			keySource = current
+		}
+		if _, ok := keySource.Shares[api.base.AccountID]; ok {
+			aesKey, err := api.dataKey(keySource)
+			if err != nil {
+				return nil, err
+			}
+			if err = secret.Decrypt(aesKey); err != nil {
+				return nil, err
+			}
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("secret %v has no version %v", sid, version)
+}
+
+// ListDueSecrets returns our secrets which are expired or due for rotation
+func (api *apiClient) ListDueSecrets() ([]*state.Secret, error) {
+	return api.base.ListDueSecrets(api.base.AccountID)
 }
 
 func (api *apiClient) DeleteSecret(sid string) error {
+	secret, err := api.base.GetSecret(sid)
+	if err != nil {
+		return err
+	}
+	if secret.NeedsApproval(nil) {
+		return api.propose(&transaction.SecretProposeData{ID: sid, Action: state.ProposalDelete, Version: secret.Version})
+	}
 	return api.base.DelSecret(sid)
 }
 
 func (api *apiClient) ListSecrets(sidPrefix string) ([]*state.Secret, error) {
-	secrets, err := api.base.ListSecrets()
+	secrets := make([]*state.Secret, 0)
+	it := api.IterateSecrets(sidPrefix)
+	for it.Next() {
+		secrets = append(secrets, it.Secret())
+	}
+	return secrets, it.Err()
+}
+
+func (api *apiClient) IterateSecrets(sidPrefix string) *SecretIterator {
+	return &SecretIterator{api: api, opts: state.ListOptions{Prefix: sidPrefix}}
+}
+
+// ListSharedSecrets returns the decrypted secrets which are shared with us
+func (api *apiClient) ListSharedSecrets(sidPrefix string) ([]*state.Secret, error) {
+	shared, err := api.base.GetSharedSecrets(api.base.AccountID)
 	if err != nil {
 		return nil, err
 	}
-	for _, s := range secrets {
-		if encryptedKey, ok := s.Shares[api.base.AccountID]; ok {
-			key, e := api.base.Key.DecryptString(encryptedKey)
-			if e != nil {
-				log.Fatal(e)
-			}
-			if e = s.Decrypt(key); e != nil {
-				log.Fatal(e)
-			}
+	ids := append(shared.Owned, shared.Readable...)
+	sort.Strings(ids)
+	secrets := make([]*state.Secret, 0, len(ids))
+	for _, id := range ids {
+		if !strings.HasPrefix(id, sidPrefix) {
+			continue
 		}
+		secret, err := api.GetSecret(id)
+		if err != nil && err != ErrSecretLocked {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
 	}
 	return secrets, nil
 }
 
-func (api *apiClient) ShareSecret(sid, accountID string, ownerRights bool) error {
+// ShareSecret shares a secret with another account or changes its role on it
+func (api *apiClient) ShareSecret(sid, accountID string, role state.Role) error {
 	secret, err := api.base.GetSecret(sid)
 	if err != nil {
 		return err
 	}
-	encryptedAESKey, ok := secret.Shares[api.base.AccountID]
-	if !ok {
-		return errors.New("no share for us on this secret")
+	if secret.Threshold > 0 {
+		return errors.New("the members of a threshold secret can't be changed by sharing it")
 	}
-	k := api.base.Key
-	aesKey, err := k.DecryptString(encryptedAESKey)
+	aesKey, err := api.dataKey(secret)
 	if err != nil {
 		return err
 	}
+	needsApproval := secret.RequiredApprovals > 1 && (role == state.RoleAdmin || secret.RoleOf(accountID) == state.RoleAdmin)
 	acc, err := api.GetAccount(accountID)
 	if err != nil {
 		log.Print("can not find account " + accountID)
@@ -199,43 +526,372 @@ func (api *apiClient) ShareSecret(sid, accountID string, ownerRights bool) error
 	if err != nil {
 		return err
 	}
-	secret.Shares[accountID] = otherEncrptedAESKey
-	if ownerRights {
-		secret.Owners[accountID] = true
+	if needsApproval {
+		if secret.Roles == nil {
+			secret.Roles = make(map[string]state.Role)
+		}
+		secret.Shares[accountID] = otherEncrptedAESKey
+		secret.Roles[accountID] = role
+		return api.propose(&transaction.SecretProposeData{ID: sid, Action: state.ProposalUpdate, Secret: secret, Version: secret.Version})
 	}
-	return api.base.UpdateSecret(secret)
+	return api.base.ShareSecret(sid, accountID, otherEncrptedAESKey, role)
 }
 
-func (api *apiClient) UpdateSecret(sid, value string) error {
+// UpdateSecret sets a new value and options. An empty value or unset options keep the current ones.
+func (api *apiClient) UpdateSecret(sid, value string, opts *SecretOptions) error {
 	sec, err := api.base.GetSecret(sid)
 	if err != nil {
 		return fmt.Errorf("failed to get secret: %v", err)
 	}
-	aesKey, err := api.base.Key.DecryptString(sec.Shares[api.base.AccountID])
+	aesKey, err := api.dataKey(sec)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt secret: %v", err)
 	}
-	sec.Value = value
-	err = sec.EncryptWithKey(aesKey)
+	// keep the ciphertext if the value doesn't change, so the chain doesn't count this as a rotation
+	encryptedValue, keepValue := sec.Value, value == "" && !sec.IsLegacy()
+	if err = sec.Decrypt(aesKey); err != nil {
+		return fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	if value != "" {
+		sec.Value = value
+	}
+	if opts != nil {
+		opts.apply(sec)
+	}
+	if sec.Threshold > 0 && !keepValue {
+		// members which unlocked the old data key must not be able to read the new value
+		err = api.rekey(sec)
+	} else {
+		// EncryptWithKey always uses the current format, so legacy secrets are migrated here
+		err = sec.EncryptWithKey(aesKey)
+	}
 	if err != nil {
 		return err
 	}
+	if keepValue {
+		sec.Value = encryptedValue
+	}
 	if err := api.base.UpdateSecret(sec); err != nil {
 		return err
 	}
 	return nil
 }
 
+// MigrateSecret re-encrypts a secret which still uses the legacy encryption format
+func (api *apiClient) MigrateSecret(sid string) error {
+	sec, err := api.base.GetSecret(sid)
+	if err != nil {
+		return fmt.Errorf("failed to get secret: %v", err)
+	}
+	if !sec.IsLegacy() {
+		return nil
+	}
+	aesKey, err := api.dataKey(sec)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	if err = sec.Decrypt(aesKey); err != nil {
+		return err
+	}
+	if err = sec.EncryptWithKey(aesKey); err != nil {
+		return err
+	}
+	return api.base.UpdateSecret(sec)
+}
+
+// UnshareSecret removes the share of an account and encrypts the secret with a new
+// data key, so the account can't decrypt future values.
 func (api *apiClient) UnshareSecret(sid, accountID string) error {
+	sec, err := api.GetSecret(sid)
+	if err != nil {
+		return err
+	}
+	if _, ok := sec.Shares[api.base.AccountID]; !ok {
+		return errors.New("no share for us on this secret")
+	}
+	needsApproval := sec.RequiredApprovals > 1 && sec.RoleOf(accountID) == state.RoleAdmin
+	delete(sec.Shares, accountID)
+	delete(sec.Roles, accountID)
+	if err = api.rekey(sec); err != nil {
+		return err
+	}
+	if needsApproval {
+		return api.propose(&transaction.SecretProposeData{ID: sid, Action: state.ProposalUnshare, AccountID: accountID, Secret: sec, Version: sec.Version})
+	}
+	return api.base.UnshareSecret(sid, accountID, sec)
+}
+
+// RotateSecretKey encrypts a secret with a new data key and wraps it for every share, it needs the admin role
+func (api *apiClient) RotateSecretKey(sid string) error {
+	sec, err := api.GetSecret(sid)
+	if err != nil {
+		return err
+	}
+	if _, ok := sec.Shares[api.base.AccountID]; !ok {
+		return errors.New("no share for us on this secret")
+	}
+	if err = api.rekey(sec); err != nil {
+		return err
+	}
+	return api.base.RotateSecretKey(sec)
+}
+
+// propose creates a proposal and returns ErrProposalCreated on success
+func (api *apiClient) propose(data *transaction.SecretProposeData) error {
+	data.SenderID = api.base.AccountID
+	if err := api.base.ProposeSecretAction(data); err != nil {
+		return err
+	}
+	return ErrProposalCreated
+}
+
+func (api *apiClient) ListProposals(sid string) ([]*state.Proposal, error) {
+	return api.base.GetProposals(sid)
+}
+
+// ApproveProposal approves a proposal of another admin, which is executed once enough admins approved it
+func (api *apiClient) ApproveProposal(sid, proposalID string) error {
+	return api.base.ApproveProposal(sid, proposalID)
+}
+
+// RequestUnlock asks the other members of a threshold secret to contribute their key shares to us
+func (api *apiClient) RequestUnlock(sid string) error {
+	return api.base.RequestUnlock(sid)
+}
+
+func (api *apiClient) ListUnlockRequests(sid string) ([]*state.UnlockRequest, error) {
+	return api.base.GetUnlockRequests(sid)
+}
+
+// ContributeUnlock encrypts our key share of a threshold secret for the requester of an unlock
+func (api *apiClient) ContributeUnlock(sid, requester string) error {
 	secret, err := api.base.GetSecret(sid)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	delete(secret.Shares, accountID)
-	delete(secret.Owners, accountID)
-	return api.base.UpdateSecret(secret)
+	encryptedShare, ok := secret.Shares[api.base.AccountID]
+	if !ok {
+		return errors.New("no share for us on this secret")
+	}
+	share, err := api.base.Key.DecryptString(encryptedShare)
+	if err != nil {
+		return err
+	}
+	acc, err := api.GetAccount(requester)
+	if err != nil {
+		return err
+	}
+	requesterKey, err := crypto.NewFromStrings(acc.PubKey, "")
+	if err != nil {
+		return err
+	}
+	contribution, err := requesterKey.EncryptToString(share)
+	if err != nil {
+		return err
+	}
+	return api.base.ContributeUnlock(sid, requester, contribution)
+}
+
+// CloseUnlock removes our unlock request of a threshold secret once we read it, so the contributed
+// key shares don't stay on the chain
+func (api *apiClient) CloseUnlock(sid string) error {
+	return api.base.CloseUnlock(sid)
+}
+
+// rekey encrypts a decrypted secret with a new data key and wraps the key for every share.
+// Threshold secrets get a Shamir share of the key for every share instead.
+func (api *apiClient) rekey(sec *state.Secret) error {
+	aesKey, err := sec.Encrypt()
+	if err != nil {
+		return err
+	}
+	accounts := make([]string, 0, len(sec.Shares))
+	for accountID := range sec.Shares {
+		accounts = append(accounts, accountID)
+	}
+	sort.Strings(accounts)
+	keys := make([][]byte, len(accounts))
+	for i := range keys {
+		keys[i] = aesKey
+	}
+	if sec.Threshold > 0 {
+		if keys, err = crypto.SplitSecret(aesKey, len(accounts), sec.Threshold); err != nil {
+			return err
+		}
+	}
+	for i, accountID := range accounts {
+		acc, err := api.GetAccount(accountID)
+		if err != nil {
+			return fmt.Errorf("can not find account %v: %v", accountID, err)
+		}
+		key, err := crypto.NewFromStrings(acc.PubKey, "")
+		if err != nil {
+			return err
+		}
+		if sec.Shares[accountID], err = key.EncryptToString(keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (api *apiClient) GiveReputation(receiver string, value int) error {
 	return api.base.GiveReputation(api.base.AccountID, receiver, value)
 }
+
+// GetParams returns the current params of the chain
+func (api *apiClient) GetParams() (*state.Params, error) {
+	return api.base.GetParams()
+}
+
+// SetParams changes the params of the chain as admin, approvals are the signatures of other admins.
+// The version of params is set to the next one.
+func (api *apiClient) SetParams(params *state.Params, approvals map[string]string) error {
+	if err := api.nextParamsVersion(params); err != nil {
+		return err
+	}
+	return api.base.SetParams(api.base.AccountID, params, approvals)
+}
+
+// ApproveParams signs a change of the params as admin, the version of params is set to the next one.
+// The approval is only valid until the params are changed.
+func (api *apiClient) ApproveParams(params *state.Params) (string, error) {
+	if err := api.nextParamsVersion(params); err != nil {
+		return "", err
+	}
+	data := &transaction.ParamsSetData{Params: params}
+	return api.base.Key.Sign(data.ApprovalHash())
+}
+
+func (api *apiClient) nextParamsVersion(params *state.Params) error {
+	current, err := api.base.GetParams()
+	if err != nil {
+		return err
+	}
+	params.Version = current.Version + 1
+	return nil
+}
+
+// CreateGroup creates a group with a new key pair and us as admin
+func (api *apiClient) CreateGroup(id string) error {
+	key, err := crypto.CreateKeyPair()
+	if err != nil {
+		return err
+	}
+	encryptedKey, err := api.base.Key.EncryptToString([]byte(key.GetPrivString()))
+	if err != nil {
+		return err
+	}
+	group := &state.Group{
+		ID:     id,
+		Keys:   map[string]string{api.base.AccountID: encryptedKey},
+		Admins: map[string]bool{api.base.AccountID: true},
+	}
+	return api.base.CreateGroup(group, key)
+}
+
+func (api *apiClient) GetGroup(id string) (*state.Group, error) {
+	return api.base.GetGroup(id)
+}
+
+func (api *apiClient) ListGroups(idPrefix string) ([]*state.Group, error) {
+	groups := make([]*state.Group, 0)
+	opts := &state.ListOptions{Prefix: idPrefix}
+	for {
+		page, err := api.base.ListGroups(opts)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, page.Groups...)
+		if page.Next == "" {
+			return groups, nil
+		}
+		opts.Start = page.Next
+	}
+}
+
+// MigrateGroup turns a legacy group, an account whose private key is stored in a secret with the same id,
+// into a group. Everyone with a share on that secret becomes a member, its admins become group admins.
+func (api *apiClient) MigrateGroup(id string) error {
+	acc, err := api.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	privkeySecret, err := api.GetSecret(id)
+	if err != nil {
+		return fmt.Errorf("cannot find shared account secret: %v", err)
+	}
+	key, err := crypto.NewFromStrings(acc.PubKey, privkeySecret.Value)
+	if err != nil {
+		return err
+	}
+	group := &state.Group{
+		ID:     id,
+		Keys:   make(map[string]string),
+		Admins: make(map[string]bool),
+	}
+	for member := range privkeySecret.Shares {
+		if member == id {
+			continue
+		}
+		memberAcc, err := api.GetAccount(member)
+		if err != nil {
+			return err
+		}
+		memberKey, err := crypto.NewFromStrings(memberAcc.PubKey, "")
+		if err != nil {
+			return err
+		}
+		if group.Keys[member], err = memberKey.EncryptToString([]byte(key.GetPrivString())); err != nil {
+			return err
+		}
+		if privkeySecret.RoleOf(member) == state.RoleAdmin {
+			group.Admins[member] = true
+		}
+	}
+	return api.base.CreateGroup(group, key)
+}
+
+// AddGroupMember encrypts the private key of the group for the new member
+func (api *apiClient) AddGroupMember(groupID, accountID string, isAdmin bool) error {
+	privKey, err := api.groupKey(groupID)
+	if err != nil {
+		return err
+	}
+	acc, err := api.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	memberKey, err := crypto.NewFromStrings(acc.PubKey, "")
+	if err != nil {
+		return err
+	}
+	encryptedKey, err := memberKey.EncryptToString([]byte(privKey))
+	if err != nil {
+		return err
+	}
+	return api.base.AddGroupMember(groupID, accountID, encryptedKey, isAdmin)
+}
+
+// RemoveGroupMember removes a member from a group and rotates the key pair of the group,
+// the new private key is encrypted for the remaining members
+func (api *apiClient) RemoveGroupMember(groupID, accountID string) error {
+	as, err := api.As(groupID)
+	if err != nil {
+		return err
+	}
+	newKey, err := crypto.CreateKeyPair()
+	if err != nil {
+		return err
+	}
+	data := &transaction.GroupMemberRemoveData{
+		ID:        groupID,
+		SenderID:  api.base.AccountID,
+		AccountID: accountID,
+		PubKey:    newKey.GetPubString(),
+	}
+	if data.Shares, data.GroupKeys, data.MemberKeys, err = as.(*apiClient).rewrapAll(newKey, accountID); err != nil {
+		return err
+	}
+	return api.base.RemoveGroupMember(data, newKey)
+}
@@ -22,11 +22,12 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 
-	"github.com/tendermint/tendermint/rpc/client"
+	abci "github.com/tendermint/abci/types"
 	"github.com/tendermint/tendermint/types"
 	"github.com/trusch/passchain/crypto"
 	"github.com/trusch/passchain/state"
@@ -36,20 +37,81 @@ import (
 type BaseClient struct {
 	Key       *crypto.Key
 	AccountID string
-	tm        client.Client
+	// Context cancels the proof of work of the transactions we send, nil means no cancellation
+	Context context.Context
+	tm      Transport
+	// collect receives the built transactions of an unsigned client
+	collect func(tx *transaction.Transaction) error
 }
 
 func NewHTTPClient(endpoint string, key *crypto.Key, account string) *BaseClient {
-	tm := client.NewHTTP(endpoint, "/websocket")
-	return &BaseClient{key, account, tm}
+	return NewClient(NewHTTPTransport(endpoint), key, account)
 }
 
-func (c *BaseClient) AddAccount(acc *state.Account) error {
-	tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{Account: acc})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+// NewClient constructs a client which uses the given transport
+func NewClient(transport Transport, key *crypto.Key, account string) *BaseClient {
+	return &BaseClient{Key: key, AccountID: account, tm: transport}
+}
+
+// NewUnsignedClient constructs a client which hands the transactions it builds to collect
+// instead of signing and broadcasting them, so they can be signed offline with Sign.
+// The key only needs to hold the public key, operations which decrypt something fail without
+// the private key. Sequence numbers are taken from the chain, so a transaction must be
+// broadcasted before the next one of the same account is built.
+func NewUnsignedClient(transport Transport, key *crypto.Key, account string, collect func(tx *transaction.Transaction) error) *BaseClient {
+	return &BaseClient{Key: key, AccountID: account, tm: transport, collect: collect}
+}
+
+// Sign solves the proof of work of a transaction with the given cost and signs it, it doesn't need network access.
+// The proof of work stops when ctx is done, its stats are returned for callers which want to report them.
+func Sign(ctx context.Context, tx *transaction.Transaction, key *crypto.Key, cost byte) (*transaction.ProofOfWorkStats, error) {
+	stats, err := tx.ProofOfWork(ctx, cost)
+	if err != nil {
+		return stats, err
+	}
+	return stats, tx.Sign(key)
+}
+
+// send signs and broadcasts a transaction, or hands it to the collector of an unsigned client.
+// The proof of work is solved for the current cost of the account the transaction is charged to.
+func (c *BaseClient) send(tx *transaction.Transaction) error {
+	if c.collect != nil {
+		return c.collect(tx)
+	}
+	account, err := tx.ChargedAccount()
+	if err != nil {
+		return err
+	}
+	cost, err := c.ProofOfWorkCost(account)
+	if err != nil {
 		return err
 	}
-	bs, _ := tx.ToBytes()
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, err := Sign(ctx, tx, c.Key, cost); err != nil {
+		return err
+	}
+	return c.Broadcast(tx)
+}
+
+// Broadcast sends a signed transaction and waits until it is committed
+func (c *BaseClient) Broadcast(tx *transaction.Transaction) error {
+	bs, err := tx.ToBytes()
+	if err != nil {
+		return err
+	}
+	return c.BroadcastTx(bs)
+}
+
+// Transport returns the transport of the client
+func (c *BaseClient) Transport() Transport {
+	return c.tm
+}
+
+// BroadcastTx sends an encoded, prepared and signed transaction and waits until it is committed
+func (c *BaseClient) BroadcastTx(bs []byte) error {
 	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
 	if err != nil {
 		return err
@@ -63,26 +125,124 @@ func (c *BaseClient) AddAccount(acc *state.Account) error {
 	return nil
 }
 
+// GetParams returns the current params of the chain
+func (c *BaseClient) GetParams() (*state.Params, error) {
+	bs, err := c.Query("/params", nil)
+	if err != nil {
+		return nil, err
+	}
+	params := &state.Params{}
+	return params, json.Unmarshal(bs, params)
+}
+
+// GetDifficulty returns the proof of work cost the next transaction of an account needs
+func (c *BaseClient) GetDifficulty(id string) (*state.Difficulty, error) {
+	bs, err := c.Query("/account/difficulty", []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	difficulty := &state.Difficulty{}
+	return difficulty, json.Unmarshal(bs, difficulty)
+}
+
+// ProofOfWorkCost returns the proof of work cost the next transaction of an account needs
+func (c *BaseClient) ProofOfWorkCost(id string) (byte, error) {
+	difficulty, err := c.GetDifficulty(id)
+	if err != nil {
+		return 0, err
+	}
+	return difficulty.ProofOfWorkCost, nil
+}
+
+// SetParams changes the params of the chain, approvals map the ids of other admins to their signatures
+func (c *BaseClient) SetParams(sender string, params *state.Params, approvals map[string]string) error {
+	tx := transaction.New(transaction.ParamsSet, &transaction.ParamsSetData{
+		SenderID:  sender,
+		Params:    params,
+		Approvals: approvals,
+	})
+	seq, err := c.nextSequence(sender)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// Query runs a raw abci query and returns its value
+func (c *BaseClient) Query(path string, data []byte) ([]byte, error) {
+	resp, err := c.tm.ABCIQuery(path, data, false)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
+	}
+	return resp.Value, nil
+}
+
+func (c *BaseClient) AddAccount(acc *state.Account) error {
+	tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{Account: acc})
+	return c.send(tx)
+}
+
 func (c *BaseClient) DelAccount(id string) error {
 	tx := transaction.New(transaction.AccountDel, &transaction.AccountDelData{ID: id})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	seq, err := c.nextSequence(id)
+	if err != nil {
 		return err
 	}
-	if err := tx.Sign(c.Key); err != nil {
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// RotateAccountKey installs a new key for an account, the proof and approvals in data must be made for sequence
+func (c *BaseClient) RotateAccountKey(data *transaction.AccountRotateKeyData, sequence uint64) error {
+	tx := transaction.New(transaction.AccountRotateKey, data)
+	tx.Sequence = sequence
+	return c.send(tx)
+}
+
+// SetGuardians sets the guardians of an account which can recover it, delay is in seconds
+func (c *BaseClient) SetGuardians(id string, guardians []string, threshold int, delay uint64) error {
+	tx := transaction.New(transaction.AccountGuardiansSet, &transaction.AccountGuardiansSetData{
+		ID:                id,
+		Guardians:         guardians,
+		GuardianThreshold: threshold,
+		RecoveryDelay:     delay,
+	})
+	seq, err := c.nextSequence(id)
+	if err != nil {
 		return err
 	}
-	bs, _ := tx.ToBytes()
-	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// RecoverAccount approves the recovery of an account with a new key as its guardian
+func (c *BaseClient) RecoverAccount(id, pubKey string) error {
+	tx := transaction.New(transaction.AccountRecover, &transaction.AccountRecoverData{
+		ID:       id,
+		SenderID: c.AccountID,
+		PubKey:   pubKey,
+	})
+	seq, err := c.nextSequence(c.AccountID)
 	if err != nil {
 		return err
 	}
-	if res.CheckTx.IsErr() {
-		return errors.New(res.CheckTx.Error())
-	}
-	if res.DeliverTx.IsErr() {
-		return errors.New(res.DeliverTx.Error())
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// CancelRecovery cancels the pending recovery of an account
+func (c *BaseClient) CancelRecovery(id string) error {
+	tx := transaction.New(transaction.AccountRecoverCancel, &transaction.AccountRecoverCancelData{ID: id})
+	seq, err := c.nextSequence(id)
+	if err != nil {
+		return err
 	}
-	return nil
+	tx.Sequence = seq
+	return c.send(tx)
 }
 
 func (c *BaseClient) GiveReputation(from, to string, value int) error {
@@ -91,24 +251,12 @@ func (c *BaseClient) GiveReputation(from, to string, value int) error {
 		To:    to,
 		Value: value,
 	})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
-		return err
-	}
-	if err := tx.Sign(c.Key); err != nil {
-		return err
-	}
-	bs, _ := tx.ToBytes()
-	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
+	seq, err := c.nextSequence(from)
 	if err != nil {
 		return err
 	}
-	if res.CheckTx.IsErr() {
-		return errors.New(res.CheckTx.Error())
-	}
-	if res.DeliverTx.IsErr() {
-		return errors.New(res.DeliverTx.Error())
-	}
-	return nil
+	tx.Sequence = seq
+	return c.send(tx)
 }
 
 func (c *BaseClient) GetAccount(id string) (*state.Account, error) {
@@ -127,36 +275,55 @@ func (c *BaseClient) GetAccount(id string) (*state.Account, error) {
 	return acc, nil
 }
 
-func (c *BaseClient) ListAccounts() ([]*state.Account, error) {
-	resp, err := c.tm.ABCIQuery("/account", nil, false)
+// nextSequence returns the sequence number the next transaction of an account must carry
+func (c *BaseClient) nextSequence(id string) (uint64, error) {
+	acc, err := c.GetAccount(id)
+	if err != nil {
+		return 0, err
+	}
+	return acc.Sequence + 1, nil
+}
+
+// ListAccounts returns one page of the accounts matching opts
+func (c *BaseClient) ListAccounts(opts *state.ListOptions) (*state.AccountPage, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.tm.ABCIQuery("/account/list", data, false)
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
-	if len(resp.Value) == 0 {
-		return nil, errors.New("account not found")
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
 	}
-	acc := []*state.Account{}
-	if err = json.Unmarshal(resp.Value, &acc); err != nil {
+	page := &state.AccountPage{}
+	if err = json.Unmarshal(resp.Value, page); err != nil {
 		return nil, err
 	}
-	return acc, nil
+	return page, nil
 }
 
-func (c *BaseClient) ListSecrets() ([]*state.Secret, error) {
-	resp, err := c.tm.ABCIQuery("/secret", nil, false)
+// ListSecrets returns one page of the secrets matching opts
+func (c *BaseClient) ListSecrets(opts *state.ListOptions) (*state.SecretPage, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.tm.ABCIQuery("/secret/list", data, false)
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
-	if len(resp.Value) == 0 {
-		return nil, errors.New("secret not found")
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
 	}
-	acc := []*state.Secret{}
-	if err = json.Unmarshal(resp.Value, &acc); err != nil {
+	page := &state.SecretPage{}
+	if err = json.Unmarshal(resp.Value, page); err != nil {
 		return nil, err
 	}
-	return acc, nil
+	return page, nil
 }
 
 func (c *BaseClient) GetSecret(id string) (*state.Secret, error) {
@@ -174,23 +341,56 @@ func (c *BaseClient) GetSecret(id string) (*state.Secret, error) {
 	return acc, nil
 }
 
-func (c *BaseClient) AddSecret(acc *state.Secret) error {
-	tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{Secret: acc})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
-		return err
+func (c *BaseClient) GetSecretHistory(id string) ([]*state.SecretVersion, error) {
+	resp, err := c.tm.ABCIQuery("/secret/history", []byte(id), false)
+	if err != nil {
+		return nil, err
 	}
-	bs, _ := tx.ToBytes()
-	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
+	if len(resp.Value) == 0 {
+		return nil, errors.New("secret history not found")
+	}
+	versions := []*state.SecretVersion{}
+	if err = json.Unmarshal(resp.Value, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (c *BaseClient) GetSharedSecrets(accountID string) (*state.SharedSecrets, error) {
+	resp, err := c.tm.ABCIQuery("/account/secrets", []byte(accountID), false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if res.CheckTx.IsErr() {
-		return errors.New(res.CheckTx.Error())
+	shared := &state.SharedSecrets{}
+	if err = json.Unmarshal(resp.Value, shared); err != nil {
+		return nil, err
 	}
-	if res.DeliverTx.IsErr() {
-		return errors.New(res.DeliverTx.Error())
+	return shared, nil
+}
+
+func (c *BaseClient) ListDueSecrets(accountID string) ([]*state.Secret, error) {
+	resp, err := c.tm.ABCIQuery("/secret/due", []byte(accountID), false)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	secrets := []*state.Secret{}
+	if err = json.Unmarshal(resp.Value, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (c *BaseClient) AddSecret(acc *state.Secret) error {
+	tx := transaction.New(transaction.SecretAdd, &transaction.SecretAddData{
+		Secret:   acc,
+		SenderID: c.AccountID,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	return c.send(tx)
 }
 
 func (c *BaseClient) DelSecret(id string) error {
@@ -198,47 +398,263 @@ func (c *BaseClient) DelSecret(id string) error {
 		ID:       id,
 		SenderID: c.AccountID,
 	})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
 		return err
 	}
-	if err := tx.Sign(c.Key); err != nil {
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+func (c *BaseClient) UpdateSecret(acc *state.Secret) error {
+	tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
+		Secret:   acc,
+		SenderID: c.AccountID,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
 		return err
 	}
-	bs, _ := tx.ToBytes()
-	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+func (c *BaseClient) ShareSecret(id, accountID, key string, role state.Role) error {
+	tx := transaction.New(transaction.SecretShare, &transaction.SecretShareData{
+		ID:        id,
+		SenderID:  c.AccountID,
+		AccountID: accountID,
+		Key:       key,
+		Role:      role,
+	})
+	seq, err := c.nextSequence(c.AccountID)
 	if err != nil {
 		return err
 	}
-	if res.CheckTx.IsErr() {
-		return errors.New(res.CheckTx.Error())
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// UnshareSecret removes the share of an account, secret is the remaining secret encrypted with a new data key
+func (c *BaseClient) UnshareSecret(id, accountID string, secret *state.Secret) error {
+	tx := transaction.New(transaction.SecretUnshare, &transaction.SecretUnshareData{
+		ID:        id,
+		SenderID:  c.AccountID,
+		AccountID: accountID,
+		Secret:    secret,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
 	}
-	if res.DeliverTx.IsErr() {
-		return errors.New(res.DeliverTx.Error())
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// RotateSecretKey stores a secret which has been encrypted with a new data key
+func (c *BaseClient) RotateSecretKey(secret *state.Secret) error {
+	tx := transaction.New(transaction.SecretRotateKey, &transaction.SecretRotateKeyData{
+		Secret:   secret,
+		SenderID: c.AccountID,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
 	}
-	return nil
+	tx.Sequence = seq
+	return c.send(tx)
 }
 
-func (c *BaseClient) UpdateSecret(acc *state.Secret) error {
-	tx := transaction.New(transaction.SecretUpdate, &transaction.SecretUpdateData{
-		Secret:   acc,
+// GetUnlockRequests returns the unlock requests of a threshold secret
+func (c *BaseClient) GetUnlockRequests(id string) ([]*state.UnlockRequest, error) {
+	resp, err := c.tm.ABCIQuery("/secret/unlocks", []byte(id), false)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
+	}
+	requests := []*state.UnlockRequest{}
+	if err = json.Unmarshal(resp.Value, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// RequestUnlock opens an unlock request for a threshold secret
+func (c *BaseClient) RequestUnlock(id string) error {
+	tx := transaction.New(transaction.SecretUnlockRequest, &transaction.SecretUnlockRequestData{
+		ID:       id,
 		SenderID: c.AccountID,
 	})
-	if err := tx.ProofOfWork(transaction.DefaultProofOfWorkCost); err != nil {
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
 		return err
 	}
-	if err := tx.Sign(c.Key); err != nil {
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// ContributeUnlock adds a key share which is encrypted for the requester to its unlock request
+func (c *BaseClient) ContributeUnlock(id, requester, share string) error {
+	tx := transaction.New(transaction.SecretUnlockContribute, &transaction.SecretUnlockContributeData{
+		ID:          id,
+		SenderID:    c.AccountID,
+		RequesterID: requester,
+		Share:       share,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
 		return err
 	}
-	bs, _ := tx.ToBytes()
-	res, err := c.tm.BroadcastTxCommit(types.Tx(bs))
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// CloseUnlock removes our unlock request of a threshold secret
+func (c *BaseClient) CloseUnlock(id string) error {
+	tx := transaction.New(transaction.SecretUnlockClose, &transaction.SecretUnlockCloseData{
+		ID:       id,
+		SenderID: c.AccountID,
+	})
+	seq, err := c.nextSequence(c.AccountID)
 	if err != nil {
 		return err
 	}
-	if res.CheckTx.IsErr() {
-		return errors.New(res.CheckTx.Error())
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// GetProposals returns the pending proposals of a secret
+func (c *BaseClient) GetProposals(id string) ([]*state.Proposal, error) {
+	resp, err := c.tm.ABCIQuery("/secret/proposals", []byte(id), false)
+	if err != nil {
+		return nil, err
 	}
-	if res.DeliverTx.IsErr() {
-		return errors.New(res.DeliverTx.Error())
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
 	}
-	return nil
+	proposals := []*state.Proposal{}
+	if err = json.Unmarshal(resp.Value, &proposals); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// ProposeSecretAction creates a proposal which other admins of the secret must approve
+func (c *BaseClient) ProposeSecretAction(data *transaction.SecretProposeData) error {
+	tx := transaction.New(transaction.SecretPropose, data)
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// ApproveProposal approves a proposal on a secret
+func (c *BaseClient) ApproveProposal(id, proposalID string) error {
+	tx := transaction.New(transaction.SecretApprove, &transaction.SecretApproveData{
+		ID:         id,
+		ProposalID: proposalID,
+		SenderID:   c.AccountID,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+func (c *BaseClient) GetGroup(id string) (*state.Group, error) {
+	resp, err := c.tm.ABCIQuery("/group", []byte(id), false)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Value) == 0 {
+		return nil, errors.New("group not found")
+	}
+	group := &state.Group{}
+	if err = json.Unmarshal(resp.Value, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// ListGroups returns one page of the groups matching opts
+func (c *BaseClient) ListGroups(opts *state.ListOptions) (*state.GroupPage, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.tm.ABCIQuery("/group/list", data, false)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != abci.CodeType_OK {
+		return nil, errors.New(resp.Log)
+	}
+	page := &state.GroupPage{}
+	if err = json.Unmarshal(resp.Value, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// CreateGroup creates a group account with the given key, which proves that we own it
+func (c *BaseClient) CreateGroup(group *state.Group, groupKey *crypto.Key) error {
+	data := &transaction.GroupCreateData{
+		Group:    group,
+		PubKey:   groupKey.GetPubString(),
+		SenderID: c.AccountID,
+	}
+	tx := transaction.New(transaction.GroupCreate, data)
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	hash, err := data.ProofHash(tx)
+	if err != nil {
+		return err
+	}
+	if data.Proof, err = groupKey.Sign(hash); err != nil {
+		return err
+	}
+	return c.send(tx)
+}
+
+func (c *BaseClient) AddGroupMember(id, accountID, key string, isAdmin bool) error {
+	tx := transaction.New(transaction.GroupMemberAdd, &transaction.GroupMemberAddData{
+		ID:        id,
+		SenderID:  c.AccountID,
+		AccountID: accountID,
+		Key:       key,
+		IsAdmin:   isAdmin,
+	})
+	seq, err := c.nextSequence(c.AccountID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	return c.send(tx)
+}
+
+// RemoveGroupMember removes a member from a group, newKey is the new key of the group which proves that we own it
+func (c *BaseClient) RemoveGroupMember(data *transaction.GroupMemberRemoveData, newKey *crypto.Key) error {
+	tx := transaction.New(transaction.GroupMemberRemove, data)
+	seq, err := c.nextSequence(data.SenderID)
+	if err != nil {
+		return err
+	}
+	tx.Sequence = seq
+	hash, err := data.ProofHash(tx)
+	if err != nil {
+		return err
+	}
+	if data.Proof, err = newKey.Sign(hash); err != nil {
+		return err
+	}
+	return c.send(tx)
 }
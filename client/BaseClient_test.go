@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package client_test
+
+import (
+	"context"
+
+	app "github.com/trusch/passchain/abci-app"
+	. "github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BaseClient", func() {
+	It("should build unsigned transactions which can be signed and broadcasted later", func() {
+		chain := app.NewApplicationFromGenesis(&state.Genesis{Params: &state.Params{ProofOfWorkCost: 4}})
+		transport := NewLocalTransport(chain)
+		pub, priv, err := NewAPIFromClient(NewClient(transport, nil, "alice")).CreateAccount("alice")
+		Expect(err).NotTo(HaveOccurred())
+		key, err := crypto.NewFromStrings(pub, priv)
+		Expect(err).NotTo(HaveOccurred())
+		pubOnly, err := crypto.NewFromStrings(pub, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		txs := []*transaction.Transaction{}
+		unsigned := NewAPIFromClient(NewUnsignedClient(transport, pubOnly, "alice", func(tx *transaction.Transaction) error {
+			txs = append(txs, tx)
+			return nil
+		}))
+		Expect(unsigned.CreateSecret("offline", "value", nil)).To(Succeed())
+		Expect(txs).To(HaveLen(1))
+		Expect(txs[0].Signature).To(BeEmpty())
+
+		alice := NewAPIFromClient(NewClient(transport, key, "alice"))
+		_, err = alice.GetSecret("offline")
+		Expect(err).To(HaveOccurred())
+
+		base := NewClient(transport, nil, "")
+		Expect(base.Broadcast(txs[0])).NotTo(Succeed())
+		cost, err := base.ProofOfWorkCost("alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cost).To(Equal(byte(4)))
+		stats, err := Sign(context.Background(), txs[0], key, cost)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.Hashes).To(BeNumerically(">", 0))
+		Expect(base.Broadcast(txs[0])).To(Succeed())
+		secret, err := alice.GetSecret("offline")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Value).To(Equal("value"))
+
+		Expect(unsigned.UpdateSecret("offline", "new value", nil)).NotTo(Succeed())
+		Expect(txs).To(HaveLen(1))
+	})
+
+	It("should keep the options which are not set on updates", func() {
+		chain := app.NewApplicationFromGenesis(&state.Genesis{Params: &state.Params{ProofOfWorkCost: 4}})
+		transport := NewLocalTransport(chain)
+		pub, priv, err := NewAPIFromClient(NewClient(transport, nil, "alice")).CreateAccount("alice")
+		Expect(err).NotTo(HaveOccurred())
+		key, err := crypto.NewFromStrings(pub, priv)
+		Expect(err).NotTo(HaveOccurred())
+		alice := NewAPIFromClient(NewClient(transport, key, "alice"))
+
+		rotateEvery, expiresAt := uint64(60), uint64(2000000000)
+		Expect(alice.CreateSecret("secret", "value", &SecretOptions{
+			Metadata:    &state.SecretMetadata{Username: "alice"},
+			RotateEvery: &rotateEvery,
+		})).To(Succeed())
+		Expect(alice.UpdateSecret("secret", "", &SecretOptions{ExpiresAt: &expiresAt})).To(Succeed())
+		secret, err := alice.GetSecret("secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.ExpiresAt).To(Equal(expiresAt))
+		Expect(secret.RotateEvery).To(Equal(rotateEvery))
+		Expect(secret.Metadata.Username).To(Equal("alice"))
+	})
+
+	It("should rotate the group key when removing a member", func() {
+		chain := app.NewApplicationFromGenesis(&state.Genesis{Params: &state.Params{ProofOfWorkCost: 4}})
+		transport := NewLocalTransport(chain)
+		newAPI := func(id string) API {
+			pub, priv, err := NewAPIFromClient(NewClient(transport, nil, id)).CreateAccount(id)
+			Expect(err).NotTo(HaveOccurred())
+			key, err := crypto.NewFromStrings(pub, priv)
+			Expect(err).NotTo(HaveOccurred())
+			return NewAPIFromClient(NewClient(transport, key, id))
+		}
+		alice, bob := newAPI("alice"), newAPI("bob")
+		Expect(alice.CreateGroup("group")).To(Succeed())
+		Expect(alice.AddGroupMember("group", "bob", false)).To(Succeed())
+		Expect(alice.CreateSecret("secret", "value", nil)).To(Succeed())
+		Expect(alice.ShareSecret("secret", "group", state.RoleReader)).To(Succeed())
+		_, err := bob.As("group")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(alice.RemoveGroupMember("group", "bob")).To(Succeed())
+		_, err = bob.As("group")
+		Expect(err).To(HaveOccurred())
+		group, err := alice.As("group")
+		Expect(err).NotTo(HaveOccurred())
+		secret, err := group.GetSecret("secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Value).To(Equal("value"))
+	})
+	It("should migrate a legacy group", func() {
+		chain := app.NewApplicationFromGenesis(&state.Genesis{Params: &state.Params{ProofOfWorkCost: 4}})
+		transport := NewLocalTransport(chain)
+		newAPI := func(id string) (API, string) {
+			pub, priv, err := NewAPIFromClient(NewClient(transport, nil, id)).CreateAccount(id)
+			Expect(err).NotTo(HaveOccurred())
+			key, err := crypto.NewFromStrings(pub, priv)
+			Expect(err).NotTo(HaveOccurred())
+			return NewAPIFromClient(NewClient(transport, key, id)), priv
+		}
+		alice, _ := newAPI("alice")
+		bob, _ := newAPI("bob")
+		_, legacyKey := newAPI("legacy")
+		Expect(alice.CreateSecret("legacy", legacyKey, nil)).To(Succeed())
+		Expect(alice.ShareSecret("legacy", "bob", state.RoleReader)).To(Succeed())
+		_, err := bob.As("legacy")
+		Expect(err).To(HaveOccurred())
+
+		Expect(alice.MigrateGroup("legacy")).To(Succeed())
+		group, err := alice.GetGroup("legacy")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(group.Admins).To(Equal(map[string]bool{"alice": true}))
+		_, err = bob.As("legacy")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
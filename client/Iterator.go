@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package client
+
+import (
+	"github.com/trusch/passchain/state"
+)
+
+// AccountIterator walks over a list of accounts and fetches the pages as needed.
+//
+//	it := api.IterateAccounts("")
+//	for it.Next() {
+//		fmt.Println(it.Account().ID)
+//	}
+//	if err := it.Err(); err != nil { ... }
+type AccountIterator struct {
+	base    *BaseClient
+	opts    state.ListOptions
+	page    []*state.Account
+	current *state.Account
+	done    bool
+	err     error
+}
+
+// Next advances to the next account, it returns false when there are no more accounts or an error occured
+func (it *AccountIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, err := it.base.ListAccounts(&it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page.Accounts
+		it.opts.Start = page.Next
+		it.done = page.Next == ""
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Account returns the current account
+func (it *AccountIterator) Account() *state.Account {
+	return it.current
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *AccountIterator) Err() error {
+	return it.err
+}
+
+// SecretIterator walks over a list of secrets, fetches the pages as needed and decrypts
+// the secrets which are shared with us.
+type SecretIterator struct {
+	api     *apiClient
+	opts    state.ListOptions
+	page    []*state.Secret
+	current *state.Secret
+	done    bool
+	err     error
+}
+
+// Next advances to the next secret, it returns false when there are no more secrets or an error occured
+func (it *SecretIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, err := it.api.base.ListSecrets(&it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page.Secrets
+		it.opts.Start = page.Next
+		it.done = page.Next == ""
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	if err := it.api.decrypt(it.current); err != nil && err != ErrSecretLocked {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+// Secret returns the current secret
+func (it *SecretIterator) Secret() *state.Secret {
+	return it.current
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *SecretIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	abci "github.com/tendermint/abci/types"
+	"github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Transport is the part of the tendermint rpc client which passchain uses
+type Transport interface {
+	ABCIQuery(path string, data data.Bytes, prove bool) (*ctypes.ResultABCIQuery, error)
+	BroadcastTxCommit(tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error)
+}
+
+// NewHTTPTransport returns a transport which talks to a tendermint node
+func NewHTTPTransport(endpoint string) Transport {
+	return client.NewHTTP(endpoint, "/websocket")
+}
+
+// LocalApplication is the part of an abci application which the local transport drives
+type LocalApplication interface {
+	Query(req abci.RequestQuery) abci.ResponseQuery
+	CheckTx(tx []byte) abci.Result
+	BeginBlock(req abci.RequestBeginBlock)
+	DeliverTx(tx []byte) abci.Result
+	EndBlock(height uint64) abci.ResponseEndBlock
+	Commit() abci.Result
+}
+
+// NewLocalTransport returns a transport which talks to an in-process application.
+// Every transaction is committed in its own block, which is useful for tests and single node setups.
+func NewLocalTransport(app LocalApplication) Transport {
+	return &localTransport{app: app}
+}
+
+type localTransport struct {
+	mutex  sync.Mutex
+	app    LocalApplication
+	height uint64
+}
+
+func (t *localTransport) ABCIQuery(path string, data data.Bytes, prove bool) (*ctypes.ResultABCIQuery, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	res := t.app.Query(abci.RequestQuery{Path: path, Data: data, Prove: prove})
+	return &ctypes.ResultABCIQuery{
+		Code:  res.Code,
+		Key:   res.Key,
+		Value: res.Value,
+		Log:   res.Log,
+	}, nil
+}
+
+func (t *localTransport) BroadcastTxCommit(tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	res := &ctypes.ResultBroadcastTxCommit{Hash: tx.Hash()}
+	res.CheckTx = t.app.CheckTx(tx)
+	if res.CheckTx.IsErr() {
+		return res, nil
+	}
+	t.height++
+	t.app.BeginBlock(abci.RequestBeginBlock{Header: &abci.Header{Height: t.height, Time: uint64(time.Now().Unix())}})
+	res.DeliverTx = t.app.DeliverTx(tx)
+	t.app.EndBlock(t.height)
+	t.app.Commit()
+	return res, nil
+}
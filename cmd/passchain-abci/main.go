@@ -29,6 +29,7 @@ import (
 	"github.com/tendermint/tmlibs/common"
 	"github.com/tendermint/tmlibs/log"
 	mapp "github.com/trusch/passchain/abci-app"
+	"github.com/trusch/passchain/state"
 )
 
 func main() {
@@ -36,12 +37,22 @@ func main() {
 	addrPtr := flag.String("addr", "tcp://0.0.0.0:46658", "Listen address")
 	abciPtr := flag.String("abci", "socket", "socket | grpc")
 	storePtr := flag.String("store", "app.ldb", "store path")
+	genesisPtr := flag.String("genesis", "", "tendermint genesis file, its app_options hold the params and admin accounts")
 	flag.Parse()
 
 	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout))
 
+	var genesis *state.Genesis
+	if *genesisPtr != "" {
+		var err error
+		if genesis, err = state.ReadGenesis(*genesisPtr); err != nil {
+			logger.Error("failed to read the genesis: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
 	// Create the application - in memory or persisted to disk
-	app := mapp.NewPersistentApplication(*storePtr)
+	app := mapp.NewPersistentApplication(*storePtr, genesis)
 
 	// Start the listener
 	srv, err := server.NewServer(*addrPtr, *abciPtr, app)
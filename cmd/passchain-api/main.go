@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/gateway"
+	"github.com/trusch/passchain/keystore"
+)
+
+func main() {
+	addrPtr := flag.String("addr", "127.0.0.1:46680", "Listen address")
+	endpointPtr := flag.String("endpoint", "http://localhost:46657", "tendermint endpoint")
+	keyPtr := flag.String("key", "", "name of the keystore key to use (default is the current key)")
+	keystorePtr := flag.String("keystore", "", "keystore directory (default is $HOME/.passchain/keys)")
+	relayPtr := flag.Bool("relay", false, "only relay pre-signed transactions, don't load a key")
+	allowRemotePtr := flag.Bool("allow-remote", false, "allow binding a gateway with a key to a non-loopback address")
+	flag.Parse()
+
+	transport := client.NewHTTPTransport(*endpointPtr)
+	var api client.API
+	if !*relayPtr {
+		api = loadAPI(transport, *keystorePtr, *keyPtr)
+	}
+	token := ""
+	if api == nil {
+		log.Print("no key loaded, only relaying pre-signed transactions")
+	} else {
+		if !isLoopback(*addrPtr) && !*allowRemotePtr {
+			log.Fatal("a gateway with a key must only listen on a loopback address, use -allow-remote to override")
+		}
+		var err error
+		if token, err = gateway.NewToken(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(token)
+		log.Print("every request must carry the token above as \"Authorization: Bearer <token>\" header")
+	}
+
+	g := gateway.New(transport, api, token)
+	g.AllowRemote = *allowRemotePtr
+	log.Printf("listening on %v", *addrPtr)
+	log.Fatal(http.ListenAndServe(*addrPtr, g))
+}
+
+// loadAPI returns an API which uses a key of the keystore, nil if there is no key to use
+func loadAPI(transport client.Transport, dir, name string) client.API {
+	if dir == "" {
+		var err error
+		dir, err = keystore.DefaultDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	ks := keystore.New(dir)
+	if name == "" {
+		current, err := ks.Current()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if current == "" {
+			return nil
+		}
+		name = current
+	}
+	kf, err := ks.Load(name)
+	if err != nil {
+		log.Fatalf("cannot load key %v: %v", name, err)
+	}
+	passphrase, err := keystore.ReadPassphrase(fmt.Sprintf("passphrase for key %v: ", name))
+	if err != nil {
+		log.Fatal("cannot read passphrase: ", err)
+	}
+	key, err := kf.Decrypt(passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("acting as account %v", kf.ID)
+	return client.NewAPIFromClient(client.NewClient(transport, key, kf.ID))
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
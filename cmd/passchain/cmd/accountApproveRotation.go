@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// accountApproveRotationCmd represents the accountApproveRotation command
+var accountApproveRotationCmd = &cobra.Command{
+	Use:   "approve-rotation <account> <new-public-key>",
+	Short: "approve the key rotation of an account you are guardian of",
+	Long: `Sign the key rotation of an account you are guardian of and print the approval.
+The approval is only valid until the account sends its next transaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			log.Fatal("you must specify the account and its new public key")
+		}
+		api := getAPI()
+		signature, err := api.ApproveKeyRotation(args[0], args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(signature)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountApproveRotationCmd)
+}
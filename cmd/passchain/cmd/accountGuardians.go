@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// accountGuardiansCmd represents the accountGuardians command
+var accountGuardiansCmd = &cobra.Command{
+	Use:   "guardians [guardian...]",
+	Short: "set the guardians which can recover your account",
+	Long: `Set the guardians which can recover your account if you lose your key.
+
+If --threshold guardians approve a recovery with "account recover", the new key
+is installed after --delay. Until then you can cancel it with "account cancel-recovery".
+Secrets shared with the lost key must be shared again with the recovered account.
+Run it without guardians to disable the recovery.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		threshold, _ := cmd.Flags().GetInt("threshold")
+		if !cmd.Flags().Changed("threshold") {
+			threshold = len(args)
+		}
+		delayFlag, _ := cmd.Flags().GetString("delay")
+		delay, err := parseDuration(delayFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		api := getAPI()
+		if err := api.SetGuardians(args, threshold, uint64(delay.Seconds())); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("set guardians %v with threshold %v", args, threshold)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountGuardiansCmd)
+	accountGuardiansCmd.Flags().Int("threshold", 0, "number of guardians needed for a recovery (default is all)")
+	accountGuardiansCmd.Flags().String("delay", "3d", "time until a recovery is executed, e.g. 3d or 12h")
+}
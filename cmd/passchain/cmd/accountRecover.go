@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// accountRecoverCmd represents the accountRecover command
+var accountRecoverCmd = &cobra.Command{
+	Use:   "recover <account> <new-public-key>",
+	Short: "approve the recovery of an account you are guardian of",
+	Long: `Approve the recovery of an account you are guardian of.
+The owner of the account generates the new key with "account keygen".
+Once enough guardians approved the same key, it is installed after the recovery delay of the account.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			log.Fatal("you must specify the account and its new public key")
+		}
+		api := getAPI()
+		if err := api.RecoverAccount(args[0], args[1]); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("approved the recovery of %v", args[0])
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountRecoverCmd)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trusch/passchain/crypto"
+)
+
+// accountRotateKeyCmd represents the accountRotateKey command
+var accountRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "replace the key of an account",
+	Long: `Replace the key of an account, e.g. if it might be compromised.
+
+All shares of secrets and keys of groups are re-encrypted for the new key.
+If the account has guardians, collect their approvals with "account approve-rotation"
+and pass them with --approval guardian=signature. The approvals are made for a specific
+new key, so generate it first with "account keygen" and pass it with --new-public-key and
+--new-private-key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		newPub, _ := cmd.Flags().GetString("new-public-key")
+		newPriv, _ := cmd.Flags().GetString("new-private-key")
+		var (
+			newKey *crypto.Key
+			err    error
+		)
+		if newPub == "" && newPriv == "" {
+			newKey, err = crypto.CreateKeyPair()
+		} else {
+			newKey, err = crypto.NewFromStrings(newPub, newPriv)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		approvalFlags, _ := cmd.Flags().GetStringSlice("approval")
+		approvals := make(map[string]string)
+		for _, approval := range approvalFlags {
+			parts := strings.SplitN(approval, "=", 2)
+			if len(parts) != 2 {
+				log.Fatal("approvals must have the form guardian=signature")
+			}
+			approvals[parts[0]] = parts[1]
+		}
+		api := getAPI()
+		if err := api.RotateAccountKey(newKey, approvals); err != nil {
+			log.Fatal("key rotation failed: ", err)
+		}
+		log.Print("successfully rotated the key")
+		log.Print("you may wish to set these env variables:")
+		fmt.Printf("export PASSCHAIN_PUBLIC_KEY=%v\n", newKey.GetPubString())
+		fmt.Printf("export PASSCHAIN_PRIVATE_KEY=%v\n", newKey.GetPrivString())
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountRotateKeyCmd)
+	accountRotateKeyCmd.Flags().String("new-public-key", "", "new public key (default is a generated one)")
+	accountRotateKeyCmd.Flags().String("new-private-key", "", "new private key")
+	accountRotateKeyCmd.Flags().StringSlice("approval", nil, "approval of a guardian as guardian=signature")
+}
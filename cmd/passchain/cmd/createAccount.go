@@ -22,11 +22,13 @@
 package cmd
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/keystore"
 )
 
 // createAccountCmd represents the createAccount command
@@ -34,7 +36,10 @@ var createAccountCmd = &cobra.Command{
 	Use:     "create",
 	Aliases: []string{"add"},
 	Short:   "create a account",
-	Long:    `Here you can create a new account`,
+	Long: `Here you can create a new account.
+
+The generated key is stored encrypted in the keystore under the name given with --key,
+which defaults to the account id.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		id := viper.GetString("id")
 		if id == "" {
@@ -43,16 +48,38 @@ var createAccountCmd = &cobra.Command{
 		if len(args) > 0 {
 			id = args[0]
 		}
-		api := getAPI()
+		name := viper.GetString("key")
+		if name == "" {
+			name = id
+		}
+		// check the name before the account exists, its key would be lost otherwise
+		if err := keystore.CheckName(name); err != nil {
+			log.Fatal(err)
+		}
+		if _, err := getKeystore().Load(name); err == nil {
+			log.Fatalf("key %v already exists in the keystore", name)
+		} else if err != keystore.ErrNoKey {
+			log.Fatal(err)
+		}
+		// ask for the passphrase first, so a typo doesn't lose the key of the new account
+		passphrase := readNewPassphrase(name)
+		endpoint := viper.GetString("endpoint")
+		api := client.NewAPI(endpoint, nil, id)
 		pub, priv, err := api.CreateAccount(id)
 		if err != nil {
 			log.Fatal("account creation failed: ", err)
 		}
 		log.Print("successfully created account ", id)
-		log.Print("you may wish to set these env variables:")
-		fmt.Printf("export PASSCHAIN_ID=%v\n", id)
-		fmt.Printf("export PASSCHAIN_PUBLIC_KEY=%v\n", pub)
-		fmt.Printf("export PASSCHAIN_PRIVATE_KEY=%v\n", priv)
+		key, err := crypto.NewFromStrings(pub, priv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		kf, err := keystore.Encrypt(name, id, endpoint, key, passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		saveKey(kf)
+		log.Print("stored the key as ", name)
 	},
 }
 
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+type groupEntry struct {
+	ID      string   `json:"id" yaml:"id"`
+	Members []string `json:"members" yaml:"members"`
+	Admins  []string `json:"admins" yaml:"admins"`
+}
+
+// groupListCmd represents the groupList command
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list groups",
+	Long:  `List groups with their members.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix, _ := cmd.Flags().GetString("prefix")
+		api := getAPI()
+		groups, err := api.ListGroups(prefix)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entries := make([]*groupEntry, len(groups))
+		for i, group := range groups {
+			entry := &groupEntry{ID: group.ID, Members: []string{}, Admins: []string{}}
+			for member := range group.Keys {
+				entry.Members = append(entry.Members, member)
+				if group.IsAdmin(member) {
+					entry.Admins = append(entry.Admins, member)
+				}
+			}
+			sort.Strings(entry.Members)
+			sort.Strings(entry.Admins)
+			entries[i] = entry
+		}
+		print(entries)
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(groupListCmd)
+	groupListCmd.Flags().String("prefix", "", "only list groups whose id starts with this prefix")
+}
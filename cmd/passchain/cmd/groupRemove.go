@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// groupRemoveCmd represents the groupRemove command
+var groupRemoveCmd = &cobra.Command{
+	Use:     "remove <group> <account>",
+	Aliases: []string{"rm"},
+	Short:   "remove a member from a group",
+	Long: `Remove a member from a group, only admins can remove members.
+
+The key pair of the group is rotated, so the removed member can't act as the group or
+read secrets which are shared with it later. It may still know the data keys of the
+secrets it could read, so rotate their keys with "secret rotate-key".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			log.Fatal("you must specify the group and the account")
+		}
+		api := getAPI()
+		if err := api.RemoveGroupMember(args[0], args[1]); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("removed %v from group %v", args[1], args[0])
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(groupRemoveCmd)
+}
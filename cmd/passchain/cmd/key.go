@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/trusch/passchain/keystore"
+)
+
+// keyCmd represents the key command
+var keyCmd = &cobra.Command{
+	Use:     "key",
+	Aliases: []string{"keys"},
+	Short:   "keystore related commands",
+	Long: `Here you can import, export, list and select the keys in your local keystore.
+
+Keys are stored in $HOME/.passchain/keys, encrypted with a passphrase.
+The passphrase is prompted for, or taken from PASSCHAIN_PASSPHRASE if it is set.`,
+}
+
+func init() {
+	RootCmd.AddCommand(keyCmd)
+}
+
+func getKeystore() *keystore.Keystore {
+	dir := viper.GetString("keystore")
+	if dir == "" {
+		var err error
+		dir, err = keystore.DefaultDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return keystore.New(dir)
+}
+
+// readPassphrase reads a passphrase from the terminal without echoing it
+func readPassphrase(prompt string) []byte {
+	passphrase, err := keystore.ReadPassphrase(prompt)
+	if err != nil {
+		log.Fatal("cannot read passphrase: ", err)
+	}
+	return passphrase
+}
+
+// readNewPassphrase reads a passphrase for a new key file and asks for confirmation
+func readNewPassphrase(name string) []byte {
+	passphrase := readPassphrase(fmt.Sprintf("new passphrase for key %v: ", name))
+	if !keystore.Interactive() {
+		return passphrase
+	}
+	if string(readPassphrase("repeat passphrase: ")) != string(passphrase) {
+		log.Fatal("passphrases do not match")
+	}
+	return passphrase
+}
+
+// saveKey encrypts and stores a key, it becomes the current key if there is none yet
+func saveKey(kf *keystore.KeyFile) {
+	ks := getKeystore()
+	if err := ks.Save(kf); err != nil {
+		log.Fatal("cannot save key: ", err)
+	}
+	current, err := ks.Current()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if current == "" {
+		if err = ks.Use(kf.Name); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("using key %v by default", kf.Name)
+	}
+}
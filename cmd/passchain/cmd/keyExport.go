@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// keyExportCmd represents the keyExport command
+var keyExportCmd = &cobra.Command{
+	Use:   "export [name]",
+	Short: "export a key from the keystore",
+	Long: `Decrypt a key and print it as env variables.
+The name defaults to the key selected with --key or "key use".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		kf := getKeyFile()
+		if len(args) > 0 {
+			var err error
+			kf, err = getKeystore().Load(args[0])
+			if err != nil {
+				log.Fatalf("cannot load key %v: %v", args[0], err)
+			}
+		}
+		if kf == nil {
+			log.Fatal("no key selected, specify a name")
+		}
+		key, err := kf.Decrypt(readPassphrase(fmt.Sprintf("passphrase for key %v: ", kf.Name)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("export PASSCHAIN_ID=%v\n", kf.ID)
+		fmt.Printf("export PASSCHAIN_PUBLIC_KEY=%v\n", key.GetPubString())
+		fmt.Printf("export PASSCHAIN_PRIVATE_KEY=%v\n", key.GetPrivString())
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(keyExportCmd)
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/keystore"
+)
+
+// keyImportCmd represents the keyImport command
+var keyImportCmd = &cobra.Command{
+	Use:   "import <name>",
+	Short: "import a key into the keystore",
+	Long: `Import the key given with --id, --public-key and --private-key (or the
+PASSCHAIN_ID, PASSCHAIN_PUBLIC_KEY and PASSCHAIN_PRIVATE_KEY env variables)
+into the keystore. The name defaults to the account id.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := viper.GetString("id")
+		if id == "" {
+			log.Fatal("you must specify --id")
+		}
+		name := id
+		if len(args) > 0 {
+			name = args[0]
+		}
+		key, err := crypto.NewFromStrings(viper.GetString("public-key"), viper.GetString("private-key"))
+		if err != nil {
+			log.Fatal("you must specify --public-key and --private-key: ", err)
+		}
+		kf, err := keystore.Encrypt(name, id, viper.GetString("endpoint"), key, readNewPassphrase(name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		saveKey(kf)
+		log.Print("successfully imported key ", name)
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(keyImportCmd)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// keyListCmd represents the keyList command
+var keyListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "list the keys in the keystore",
+	Long:    `List the keys in the keystore, the private keys stay encrypted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ks := getKeystore()
+		keys, err := ks.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		current, err := ks.Current()
+		if err != nil {
+			log.Fatal(err)
+		}
+		type entry struct {
+			Name     string `json:"name" yaml:"name"`
+			ID       string `json:"id" yaml:"id"`
+			Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+			PubKey   string `json:"pubkey" yaml:"pubkey"`
+			Current  bool   `json:"current,omitempty" yaml:"current,omitempty"`
+		}
+		res := make([]entry, 0, len(keys))
+		for _, kf := range keys {
+			res = append(res, entry{kf.Name, kf.ID, kf.Endpoint, kf.PubKey, kf.Name == current})
+		}
+		print(res)
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(keyListCmd)
+}
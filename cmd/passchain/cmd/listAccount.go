@@ -25,6 +25,7 @@ import (
 	"log"
 
 	"github.com/spf13/cobra"
+	"github.com/trusch/passchain/state"
 )
 
 // listAccountCmd represents the listAccount command
@@ -33,9 +34,15 @@ var listAccountCmd = &cobra.Command{
 	Short: "list accounts",
 	Long:  `List accounts.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		prefix, _ := cmd.Flags().GetString("prefix")
+		limit, _ := cmd.Flags().GetInt("limit")
 		api := getAPI()
-		accs, err := api.ListAccounts("")
-		if err != nil {
+		accs := make([]*state.Account, 0)
+		it := api.IterateAccounts(prefix)
+		for (limit <= 0 || len(accs) < limit) && it.Next() {
+			accs = append(accs, it.Account())
+		}
+		if err := it.Err(); err != nil {
 			log.Fatal(err)
 		}
 		print(accs)
@@ -44,4 +51,6 @@ var listAccountCmd = &cobra.Command{
 
 func init() {
 	accountCmd.AddCommand(listAccountCmd)
+	listAccountCmd.Flags().String("prefix", "", "only list accounts whose id starts with this prefix")
+	listAccountCmd.Flags().Int("limit", 0, "maximum number of accounts to list, 0 lists all")
 }
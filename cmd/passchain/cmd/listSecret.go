@@ -25,6 +25,7 @@ import (
 	"log"
 
 	"github.com/spf13/cobra"
+	"github.com/trusch/passchain/state"
 )
 
 // listSecretCmd represents the listSecret command
@@ -33,9 +34,26 @@ var listSecretCmd = &cobra.Command{
 	Short: "list secrets",
 	Long:  `List secrets and decrypt if possible.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		prefix, _ := cmd.Flags().GetString("prefix")
+		limit, _ := cmd.Flags().GetInt("limit")
 		api := getAPI()
-		secrets, err := api.ListSecrets("")
-		if err != nil {
+		if mine, _ := cmd.Flags().GetBool("mine"); mine {
+			secrets, err := api.ListSharedSecrets(prefix)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if limit > 0 && len(secrets) > limit {
+				secrets = secrets[:limit]
+			}
+			print(secrets)
+			return
+		}
+		secrets := make([]*state.Secret, 0)
+		it := api.IterateSecrets(prefix)
+		for (limit <= 0 || len(secrets) < limit) && it.Next() {
+			secrets = append(secrets, it.Secret())
+		}
+		if err := it.Err(); err != nil {
 			log.Fatal(err)
 		}
 		print(secrets)
@@ -44,4 +62,7 @@ var listSecretCmd = &cobra.Command{
 
 func init() {
 	secretCmd.AddCommand(listSecretCmd)
+	listSecretCmd.Flags().String("prefix", "", "only list secrets whose id starts with this prefix")
+	listSecretCmd.Flags().Int("limit", 0, "maximum number of secrets to list, 0 lists all")
+	listSecretCmd.Flags().Bool("mine", false, "only list secrets which are shared with you")
 }
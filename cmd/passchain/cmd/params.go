@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/state"
+)
+
+// paramsCmd represents the params command
+var paramsCmd = &cobra.Command{
+	Use:   "params",
+	Short: "chain params related commands",
+	Long: `Here you can inspect the params of the chain, like the proof of work cost of transactions.
+
+The params are changed by the admins listed in them. A change needs the approval of
+as many admins as the admin threshold says, collect them with "params approve".`,
+}
+
+// paramsFromFlags returns the current params of the chain with the changes given by the flags
+func paramsFromFlags(cmd *cobra.Command, api client.API) *state.Params {
+	params, err := api.GetParams()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cmd.Flags().Changed("cost") {
+		cost, _ := cmd.Flags().GetInt("cost")
+		if cost < 0 || cost > state.MaxProofOfWorkCost {
+			log.Fatal("invalid proof of work cost")
+		}
+		params.ProofOfWorkCost = byte(cost)
+	}
+	if cmd.Flags().Changed("admins") {
+		params.Admins, _ = cmd.Flags().GetStringSlice("admins")
+	}
+	if cmd.Flags().Changed("admin-threshold") {
+		params.AdminThreshold, _ = cmd.Flags().GetInt("admin-threshold")
+	}
+	if cmd.Flags().Changed("tx-budget") {
+		params.TxBudget, _ = cmd.Flags().GetInt("tx-budget")
+	}
+	if cmd.Flags().Changed("budget-blocks") {
+		params.BudgetBlocks, _ = cmd.Flags().GetUint64("budget-blocks")
+	}
+	if cmd.Flags().Changed("reputation-per-bit") {
+		params.ReputationPerBit, _ = cmd.Flags().GetInt("reputation-per-bit")
+	}
+	if cmd.Flags().Changed("min-cost") {
+		cost, _ := cmd.Flags().GetInt("min-cost")
+		if cost < 0 || cost > state.MaxProofOfWorkCost {
+			log.Fatal("invalid minimal proof of work cost")
+		}
+		params.MinProofOfWorkCost = byte(cost)
+	}
+	if err = params.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return params
+}
+
+func addParamsFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("cost", 0, "proof of work cost of transactions (default is the current one)")
+	cmd.Flags().StringSlice("admins", nil, "accounts which may change the params (default are the current ones)")
+	cmd.Flags().Int("admin-threshold", 0, "number of admins which must approve a change (default is the current one)")
+	cmd.Flags().Int("tx-budget", 0, "transactions per budget window at the proof of work cost, 0 disables budgets (default is the current one)")
+	cmd.Flags().Uint64("budget-blocks", 0, "length of a budget window in blocks (default is the current one)")
+	cmd.Flags().Int("reputation-per-bit", 0, "reputation which lowers the cost by one bit, 0 disables the discount (default is the current one)")
+	cmd.Flags().Int("min-cost", 0, "lowest cost the reputation discount can reach (default is the current one)")
+}
+
+func init() {
+	RootCmd.AddCommand(paramsCmd)
+}
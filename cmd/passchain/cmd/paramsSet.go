@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// paramsSetCmd represents the paramsSet command
+var paramsSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "change the params of the chain",
+	Long: `Change the params of the chain as admin. Params which aren't given keep their value.
+
+If the admin threshold is higher than one, collect the approvals of other admins with
+"params approve" using the same flags and pass them with --approval admin=signature.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		approvalFlags, _ := cmd.Flags().GetStringSlice("approval")
+		approvals := make(map[string]string)
+		for _, approval := range approvalFlags {
+			parts := strings.SplitN(approval, "=", 2)
+			if len(parts) != 2 {
+				log.Fatal("approvals must have the form admin=signature")
+			}
+			approvals[parts[0]] = parts[1]
+		}
+		api := getAPI()
+		params := paramsFromFlags(cmd, api)
+		if err := api.SetParams(params, approvals); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("successfully changed the params to version %v", params.Version)
+	},
+}
+
+func init() {
+	paramsCmd.AddCommand(paramsSetCmd)
+	addParamsFlags(paramsSetCmd)
+	paramsSetCmd.Flags().StringSlice("approval", nil, "approval of another admin as admin=signature")
+}
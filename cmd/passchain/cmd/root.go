@@ -22,10 +22,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -34,6 +36,8 @@ import (
 	"github.com/spf13/viper"
 	"github.com/trusch/passchain/client"
 	"github.com/trusch/passchain/crypto"
+	"github.com/trusch/passchain/keystore"
+	"github.com/trusch/passchain/transaction"
 )
 
 var cfgFile string
@@ -67,11 +71,16 @@ func init() {
 	RootCmd.PersistentFlags().String("format", "yaml", "output format")
 	RootCmd.PersistentFlags().String("as", "", "group account to use")
 	RootCmd.PersistentFlags().String("endpoint", "http://localhost:46657", "tendermint endpoint")
+	RootCmd.PersistentFlags().String("key", "", "name of the keystore key to use (default is the one selected with \"key use\")")
+	RootCmd.PersistentFlags().String("keystore", "", "keystore directory (default is $HOME/.passchain/keys)")
+	RootCmd.PersistentFlags().String("unsigned", "", "write unsigned transactions to this file instead of broadcasting them, see \"tx sign\"")
 
 	viper.BindPFlags(RootCmd.PersistentFlags())
 	viper.BindEnv("id", "PASSCHAIN_ID")
 	viper.BindEnv("public-key", "PASSCHAIN_PUBLIC_KEY")
 	viper.BindEnv("private-key", "PASSCHAIN_PRIVATE_KEY")
+	viper.BindEnv("key", "PASSCHAIN_KEY")
+	viper.BindEnv("keystore", "PASSCHAIN_KEYSTORE")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -101,10 +110,15 @@ func initConfig() {
 }
 
 func getAPI() client.API {
-	account := viper.GetString("id")
-	key := getKey()
-	endpoint := viper.GetString("endpoint")
-	api := client.NewAPI(endpoint, key, account)
+	var api client.API
+	if file := viper.GetString("unsigned"); file != "" {
+		api = getUnsignedAPI(file)
+	} else {
+		key, account, endpoint := getIdentity()
+		base := client.NewHTTPClient(endpoint, key, account)
+		base.Context = interruptContext()
+		api = client.NewAPIFromClient(base)
+	}
 	if as := viper.GetString("as"); as != "" {
 		a, err := api.As(as)
 		if err != nil {
@@ -115,17 +129,129 @@ func getAPI() client.API {
 	return api
 }
 
-func getKey() *crypto.Key {
-	k, err := crypto.NewFromStrings(viper.GetString("public-key"), viper.GetString("private-key"))
-	if err != nil {
+// interruptContext returns a context which is cancelled by the first interrupt,
+// so a running proof of work stops cleanly. A second interrupt kills the command.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		cancel()
+	}()
+	return ctx
+}
+
+// getIdentity returns the key, account id and endpoint to use.
+// Keys given via flags, env or config take precedence over the keystore.
+func getIdentity() (*crypto.Key, string, string) {
+	account := viper.GetString("id")
+	endpoint := viper.GetString("endpoint")
+	k, kf := loadKey()
+	if k == nil {
 		log.Print("generate ephemeral key...")
+		var err error
 		k, err = crypto.CreateKeyPair()
 		if err != nil {
 			log.Fatal(err)
 		}
-		return k
+		return k, account, endpoint
+	}
+	if kf != nil {
+		account, endpoint = applyKeyFile(kf, account, endpoint)
+	}
+	return k, account, endpoint
+}
+
+// loadKey returns the key given via flags, env or config, or else the decrypted keystore key
+// together with its key file. It returns nil if there is no key.
+func loadKey() (*crypto.Key, *keystore.KeyFile) {
+	k, err := crypto.NewFromStrings(viper.GetString("public-key"), viper.GetString("private-key"))
+	if err == nil {
+		return k, nil
+	}
+	kf := getKeyFile()
+	if kf == nil {
+		return nil, nil
+	}
+	k, err = kf.Decrypt(readPassphrase(fmt.Sprintf("passphrase for key %v: ", kf.Name)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return k, kf
+}
+
+// getPublicIdentity is like getIdentity, but the key only holds the public key,
+// so no passphrase is needed
+func getPublicIdentity() (*crypto.Key, string, string) {
+	account := viper.GetString("id")
+	endpoint := viper.GetString("endpoint")
+	if pub := viper.GetString("public-key"); pub != "" {
+		k, err := crypto.NewFromStrings(pub, "")
+		if err != nil {
+			log.Fatal(err)
+		}
+		return k, account, endpoint
+	}
+	kf := getKeyFile()
+	if kf == nil {
+		log.Fatal("you must specify --public-key or a keystore key")
+	}
+	k, err := crypto.NewFromStrings(kf.PubKey, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	account, endpoint = applyKeyFile(kf, account, endpoint)
+	return k, account, endpoint
+}
+
+// applyKeyFile fills in the account id and endpoint of a key file unless they were given explicitly
+func applyKeyFile(kf *keystore.KeyFile, account, endpoint string) (string, string) {
+	if account == "" {
+		account = kf.ID
+	}
+	if kf.Endpoint != "" && !RootCmd.PersistentFlags().Changed("endpoint") {
+		endpoint = kf.Endpoint
+	}
+	return account, endpoint
+}
+
+// getUnsignedAPI returns an API which writes the transactions it builds to file
+func getUnsignedAPI(file string) client.API {
+	if _, err := os.Stat(file); err == nil {
+		log.Fatalf("%v already exists, broadcast it before building new transactions", file)
+	}
+	key, account, endpoint := getPublicIdentity()
+	txs := []*transaction.Transaction{}
+	collect := func(tx *transaction.Transaction) error {
+		txs = append(txs, tx)
+		log.Printf("wrote unsigned %v transaction to %v", tx.Type, file)
+		return transaction.WriteFile(file, txs)
+	}
+	base := client.NewUnsignedClient(client.NewHTTPTransport(endpoint), key, account, collect)
+	return client.NewAPIFromClient(base)
+}
+
+// getKeyFile returns the key file selected with --key or "key use", nil if there is none
+func getKeyFile() *keystore.KeyFile {
+	ks := getKeystore()
+	name := viper.GetString("key")
+	if name == "" {
+		current, err := ks.Current()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if current == "" {
+			return nil
+		}
+		name = current
+	}
+	kf, err := ks.Load(name)
+	if err != nil {
+		log.Fatalf("cannot load key %v: %v", name, err)
 	}
-	return k
+	return kf
 }
 
 func print(data interface{}) {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
 )
 
 var secretData string
@@ -35,7 +36,10 @@ var secretAddCmd = &cobra.Command{
 	Use:     "create",
 	Aliases: []string{"add"},
 	Short:   "create a secret",
-	Long:    `Create a secret.`,
+	Long: `Create a secret.
+
+With --threshold the data key is split into key shares for you and every --member,
+and the given number of members is needed to read the secret, see "secret unlock".`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sid := viper.GetString("sid")
 		if len(args) > 0 {
@@ -48,8 +52,25 @@ var secretAddCmd = &cobra.Command{
 		if sid == "" || data == "" {
 			log.Fatal("you must specify --sid and --data")
 		}
+		opts, err := secretOptionsFromFlags(cmd, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if approvals, _ := cmd.Flags().GetInt("required-approvals"); approvals > 0 {
+			if opts == nil {
+				opts = &client.SecretOptions{}
+			}
+			opts.RequiredApprovals = approvals
+		}
+		if threshold, _ := cmd.Flags().GetInt("threshold"); threshold > 0 {
+			if opts == nil {
+				opts = &client.SecretOptions{}
+			}
+			opts.Threshold = threshold
+			opts.Members, _ = cmd.Flags().GetStringSlice("member")
+		}
 		api := getAPI()
-		if err := api.CreateSecret(sid, data); err != nil {
+		if err := api.CreateSecret(sid, data, opts); err != nil {
 			log.Fatal(err)
 		}
 		log.Printf("created secret %v", sid)
@@ -59,4 +80,8 @@ var secretAddCmd = &cobra.Command{
 func init() {
 	secretCmd.AddCommand(secretAddCmd)
 	secretAddCmd.Flags().StringVar(&secretData, "data", "", "secret value")
+	addSecretOptionFlags(secretAddCmd)
+	secretAddCmd.Flags().Int("threshold", 0, "number of members needed to read the secret")
+	secretAddCmd.Flags().StringSlice("member", nil, "members of a threshold secret besides you")
+	secretAddCmd.Flags().Int("required-approvals", 0, "number of admins which must approve deleting the secret or changing its admins")
 }
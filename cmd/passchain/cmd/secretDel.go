@@ -26,6 +26,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
 )
 
 // secretDelCmd represents the secretDel command
@@ -43,7 +44,10 @@ var secretDelCmd = &cobra.Command{
 			log.Fatal("you must specify --sid")
 		}
 		api := getAPI()
-		if err := api.DeleteSecret(id); err != nil {
+		if err := api.DeleteSecret(id); err == client.ErrProposalCreated {
+			log.Print(err)
+			return
+		} else if err != nil {
 			log.Fatal(err)
 		}
 		log.Print("successfully deleted secret ", id)
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type secretDueEntry struct {
+	ID     string `json:"id" yaml:"id"`
+	Reason string `json:"reason" yaml:"reason"`
+	DueAt  string `json:"dueAt" yaml:"dueAt"`
+}
+
+// secretDueCmd represents the secretDue command
+var secretDueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "list secrets which are expired or due for rotation",
+	Long: `List all secrets shared with you which are expired or due for rotation.
+Exits with code 1 if there is any, so it can be used in cron jobs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		api := getAPI()
+		secrets, err := api.ListDueSecrets()
+		if err != nil {
+			log.Fatal(err)
+		}
+		now := uint64(time.Now().Unix())
+		entries := make([]*secretDueEntry, 0, len(secrets))
+		for _, s := range secrets {
+			if s.IsExpired(now) {
+				entries = append(entries, &secretDueEntry{s.ID, "expired", formatUnix(s.ExpiresAt)})
+			}
+			if s.IsRotationDue(now) {
+				entries = append(entries, &secretDueEntry{s.ID, "rotation due", formatUnix(s.RotationDueAt())})
+			}
+		}
+		print(entries)
+		if len(entries) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func formatUnix(t uint64) string {
+	return time.Unix(int64(t), 0).Format(time.RFC3339)
+}
+
+func init() {
+	secretCmd.AddCommand(secretDueCmd)
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/state"
 )
 
 // secretGetCmd represents the secretGet command
@@ -40,14 +41,30 @@ var secretGetCmd = &cobra.Command{
 			sid = args[0]
 		}
 		api := getAPI()
-		secret, err := api.GetSecret(sid)
+		var (
+			secret *state.Secret
+			err    error
+		)
+		if cmd.Flags().Changed("version") {
+			version, _ := cmd.Flags().GetUint64("version")
+			secret, err = api.GetSecretVersion(sid, version)
+		} else {
+			secret, err = api.GetSecret(sid)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
 		fmt.Println(secret.Value)
+		if secret.Threshold > 0 {
+			// the contributed key shares are consumed now
+			if err = api.CloseUnlock(sid); err != nil {
+				log.Fatal(err)
+			}
+		}
 	},
 }
 
 func init() {
 	secretCmd.AddCommand(secretGetCmd)
+	secretGetCmd.Flags().Uint64("version", 0, "get an older version of the secret")
 }
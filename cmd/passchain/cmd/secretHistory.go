@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type secretHistoryEntry struct {
+	Version uint64 `json:"version" yaml:"version"`
+	Height  uint64 `json:"height" yaml:"height"`
+	Author  string `json:"author" yaml:"author"`
+}
+
+// secretHistoryCmd represents the secretHistory command
+var secretHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "list the versions of a secret",
+	Long:  `List all versions of a secret. Use 'secret get --version' to retrieve one of them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sid := viper.GetString("sid")
+		if len(args) > 0 {
+			sid = args[0]
+		}
+		if sid == "" {
+			log.Fatal("you must specify --sid")
+		}
+		api := getAPI()
+		versions, err := api.GetSecretHistory(sid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entries := make([]*secretHistoryEntry, len(versions))
+		for i, v := range versions {
+			entries[i] = &secretHistoryEntry{v.Version, v.Height, v.Author}
+		}
+		print(entries)
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretHistoryCmd)
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/state"
+)
+
+// addSecretOptionFlags registers the flags to set the optional attributes of a secret
+func addSecretOptionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("expires", "", "expiry of the secret as date (2006-01-02 or RFC3339), duration from now (e.g. 90d) or 'never'")
+	cmd.Flags().String("rotate-every", "", "rotation interval of the value (e.g. 90d or 2160h), 0 disables rotation reminders")
+	cmd.Flags().String("description", "", "description of the secret")
+	cmd.Flags().String("username", "", "username belonging to the secret")
+	cmd.Flags().String("url", "", "url belonging to the secret")
+	cmd.Flags().String("notes", "", "notes about the secret")
+	cmd.Flags().StringSlice("tag", nil, "tags of the secret")
+	cmd.Flags().StringSlice("field", nil, "custom fields as key=value, an empty value removes the field")
+	cmd.Flags().StringSlice("encrypt", nil, "metadata fields to encrypt (description, username, url, notes or fields.<key>)")
+}
+
+// secretOptionsFromFlags returns the options which are set by flags, the metadata flags are applied
+// to the metadata of current. It returns nil if no option flag has been set.
+func secretOptionsFromFlags(cmd *cobra.Command, current *state.Secret) (*client.SecretOptions, error) {
+	opts := &client.SecretOptions{}
+	var currentMeta *state.SecretMetadata
+	if current != nil {
+		currentMeta = current.Metadata
+	}
+	meta, err := metadataFromFlags(cmd, currentMeta)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil && !cmd.Flags().Changed("expires") && !cmd.Flags().Changed("rotate-every") {
+		return nil, nil
+	}
+	opts.Metadata = meta
+	if cmd.Flags().Changed("expires") {
+		expires, _ := cmd.Flags().GetString("expires")
+		expiresAt, err := parseExpiry(expires, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		opts.ExpiresAt = &expiresAt
+	}
+	if cmd.Flags().Changed("rotate-every") {
+		rotateEvery, _ := cmd.Flags().GetString("rotate-every")
+		d, err := parseDuration(rotateEvery)
+		if err != nil {
+			return nil, err
+		}
+		seconds := uint64(d / time.Second)
+		opts.RotateEvery = &seconds
+	}
+	return opts, nil
+}
+
+// parseExpiry parses a date or a duration relative to now into a unix time
+func parseExpiry(expiry string, now time.Time) (uint64, error) {
+	if expiry == "" || expiry == "never" {
+		return 0, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, expiry); err == nil {
+			return uint64(t.Unix()), nil
+		}
+	}
+	d, err := parseDuration(expiry)
+	if err != nil {
+		return 0, fmt.Errorf("malformed expiry %v", expiry)
+	}
+	return uint64(now.Add(d).Unix()), nil
+}
+
+// parseDuration is like time.ParseDuration but also accepts a number of days like "90d"
+func parseDuration(str string) (time.Duration, error) {
+	if strings.HasSuffix(str, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(str, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("malformed duration %v", str)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(str)
+}
+
+// metadataFromFlags applies the changed metadata flags to a copy of current.
+// It returns nil if no metadata flag has been set.
+func metadataFromFlags(cmd *cobra.Command, current *state.SecretMetadata) (*state.SecretMetadata, error) {
+	changed := false
+	for _, name := range []string{"description", "username", "url", "notes", "tag", "field", "encrypt"} {
+		changed = changed || cmd.Flags().Changed(name)
+	}
+	if !changed {
+		return nil, nil
+	}
+	meta := &state.SecretMetadata{}
+	if current != nil {
+		*meta = *current
+	}
+	for name, field := range map[string]*string{
+		"description": &meta.Description,
+		"username":    &meta.Username,
+		"url":         &meta.URL,
+		"notes":       &meta.Notes,
+	} {
+		if cmd.Flags().Changed(name) {
+			*field, _ = cmd.Flags().GetString(name)
+		}
+	}
+	if cmd.Flags().Changed("tag") {
+		meta.Tags, _ = cmd.Flags().GetStringSlice("tag")
+	}
+	if cmd.Flags().Changed("field") {
+		fields := make(map[string]string)
+		for k, v := range meta.Fields {
+			fields[k] = v
+		}
+		pairs, _ := cmd.Flags().GetStringSlice("field")
+		for _, pair := range pairs {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, fmt.Errorf("malformed field %v, expected key=value", pair)
+			}
+			if parts[1] == "" {
+				delete(fields, parts[0])
+				continue
+			}
+			fields[parts[0]] = parts[1]
+		}
+		meta.Fields = fields
+	}
+	if cmd.Flags().Changed("encrypt") {
+		meta.Encrypted, _ = cmd.Flags().GetStringSlice("encrypt")
+	}
+	return meta, nil
+}
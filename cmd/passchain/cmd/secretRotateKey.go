@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// secretRotateKeyCmd represents the secretRotateKey command
+var secretRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "encrypt a secret with a new key",
+	Long: `Encrypt a secret with a new key and share the key with all accounts which have access to the secret.
+Only admins of the secret can rotate its key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sid := viper.GetString("sid")
+		if len(args) > 0 {
+			sid = args[0]
+		}
+		if sid == "" {
+			log.Fatal("you must specify --sid")
+		}
+		api := getAPI()
+		if err := api.RotateSecretKey(sid); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("rotated the key of secret %v", sid)
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretRotateKeyCmd)
+}
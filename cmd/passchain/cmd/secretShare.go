@@ -26,13 +26,15 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/state"
 )
 
 // secretShareCmd represents the secretShare command
 var secretShareCmd = &cobra.Command{
 	Use:   "share",
 	Short: "share a secret",
-	Long:  `Share a secret with another account.`,
+	Long:  `Share a secret with another account or change the role of an account on it.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sid, _ := cmd.Flags().GetString("sid")
 		if len(args) > 0 && sid == "" {
@@ -42,17 +44,29 @@ var secretShareCmd = &cobra.Command{
 		if len(args) > 1 && with == "" {
 			with = args[1]
 		}
+		role, err := state.ParseRole(viper.GetString("role"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if viper.GetBool("owner") {
+			role = state.RoleAdmin
+		}
 		api := getAPI()
-		if err := api.ShareSecret(sid, with, viper.GetBool("owner")); err != nil {
+		if err := api.ShareSecret(sid, with, role); err == client.ErrProposalCreated {
+			log.Print(err)
+			return
+		} else if err != nil {
 			log.Fatal(err)
 		}
-		log.Printf("successfully shared %v with %v", sid, with)
+		log.Printf("successfully shared %v with %v as %v", sid, with, role)
 	},
 }
 
 func init() {
 	secretCmd.AddCommand(secretShareCmd)
 	secretShareCmd.Flags().String("with", "", "who to share with")
+	secretShareCmd.Flags().String("role", string(state.RoleReader), "role to grant: reader, writer, sharer or admin")
 	secretShareCmd.Flags().Bool("owner", false, "share owner rights (read only if false)")
+	secretShareCmd.Flags().MarkDeprecated("owner", "use --role admin instead")
 	viper.BindPFlags(secretShareCmd.Flags())
 }
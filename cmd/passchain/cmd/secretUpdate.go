@@ -32,21 +32,32 @@ import (
 var secretUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "update a secret",
-	Long:  `Update a secrets value but retain the shares`,
+	Long:  `Update a secrets value or metadata but retain the shares`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sid := viper.GetString("sid")
 		if sid == "" && len(args) > 0 {
 			sid = args[0]
 		}
-		data := secretData
+		data := viper.GetString("data")
 		if data == "" && len(args) > 1 {
 			data = args[1]
 		}
-		if sid == "" || data == "" {
-			log.Fatal("you must specify --sid and --data")
+		if sid == "" {
+			log.Fatal("you must specify --sid")
 		}
 		api := getAPI()
-		if err := api.UpdateSecret(sid, data); err != nil {
+		current, err := api.GetSecret(sid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts, err := secretOptionsFromFlags(cmd, current)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if data == "" && opts == nil {
+			log.Fatal("you must specify --data or options to update")
+		}
+		if err := api.UpdateSecret(sid, data, opts); err != nil {
 			log.Fatal(err)
 		}
 		log.Printf("updated secret %v", sid)
@@ -57,4 +68,5 @@ func init() {
 	secretCmd.AddCommand(secretUpdateCmd)
 	secretUpdateCmd.PersistentFlags().String("data", "", "secret value")
 	viper.BindPFlags(secretUpdateCmd.PersistentFlags())
+	addSecretOptionFlags(secretUpdateCmd)
 }
@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// txCmd represents the tx command
+var txCmd = &cobra.Command{
+	Use:     "tx",
+	Aliases: []string{"transactions"},
+	Short:   "offline signing of transactions",
+	Long: `Here you can sign transactions offline and broadcast them later.
+
+Build transactions on a machine with network access by passing --unsigned <file>
+to any command, e.g. "passchain --unsigned tx.json secret create my-secret value".
+This only needs the public key. Copy the file to the machine which holds the
+private key, sign it with "tx sign" and broadcast it with "tx broadcast".`,
+}
+
+func init() {
+	RootCmd.AddCommand(txCmd)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/transaction"
+)
+
+// txBroadcastCmd represents the txBroadcast command
+var txBroadcastCmd = &cobra.Command{
+	Use:   "broadcast <file>",
+	Short: "broadcast signed transactions",
+	Long:  `Broadcast the signed transactions in a file in order, this needs no key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatal("you must specify a file")
+		}
+		txs, err := transaction.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		base := client.NewHTTPClient(viper.GetString("endpoint"), nil, "")
+		for i, tx := range txs {
+			if err = base.Broadcast(tx); err != nil {
+				log.Fatalf("failed to broadcast transaction %v (%v): %v", i, tx.Type, err)
+			}
+			log.Printf("broadcasted %v transaction", tx.Type)
+		}
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txBroadcastCmd)
+}
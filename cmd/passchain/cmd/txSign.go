@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+)
+
+// txSignCmd represents the txSign command
+var txSignCmd = &cobra.Command{
+	Use:   "sign <file> [output file]",
+	Short: "sign transactions",
+	Long: `Solve the proof of work of the transactions in a file and sign them.
+The file is replaced unless an output file is given. The proof of work cost of each
+transaction is queried from the chain, pass it with --cost to sign without network
+access, see "passchain account difficulty".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 || len(args) > 2 {
+			log.Fatal("you must specify a file")
+		}
+		out := args[0]
+		if len(args) == 2 {
+			out = args[1]
+		}
+		txs, err := transaction.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		key, _ := loadKey()
+		if key == nil {
+			log.Fatal("you must specify a key")
+		}
+		var base *client.BaseClient
+		cost, _ := cmd.Flags().GetInt("cost")
+		if !cmd.Flags().Changed("cost") {
+			base = client.NewHTTPClient(viper.GetString("endpoint"), nil, "")
+		} else if cost < 0 || cost > state.MaxProofOfWorkCost {
+			log.Fatal("invalid proof of work cost")
+		}
+		ctx := interruptContext()
+		for _, tx := range txs {
+			if base != nil {
+				account, err := tx.ChargedAccount()
+				if err != nil {
+					log.Fatal(err)
+				}
+				accountCost, err := base.ProofOfWorkCost(account)
+				if err != nil {
+					log.Fatalf("the proof of work cost of %v is unknown, pass it with --cost: %v", account, err)
+				}
+				cost = int(accountCost)
+			}
+			log.Printf("signing %v transaction with sequence %v", tx.Type, tx.Sequence)
+			stats, err := client.Sign(ctx, tx, key, byte(cost))
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("solved proof of work of cost %v with %v hashes in %v (%.0f hashes/s)", cost, stats.Hashes, stats.Duration, stats.HashRate())
+		}
+		if err = transaction.WriteFile(out, txs); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("signed %v transactions\n", len(txs))
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txSignCmd)
+	txSignCmd.Flags().Int("cost", 0, "proof of work cost of the transactions, queried from the chain if not given")
+}
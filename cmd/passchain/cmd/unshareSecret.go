@@ -26,13 +26,14 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trusch/passchain/client"
 )
 
 // secretUnshareCmd represents the secretUnshare command
 var secretUnshareCmd = &cobra.Command{
 	Use:   "unshare",
 	Short: "unshare a secret",
-	Long:  `Unshare a secret with another account.`,
+	Long:  `Unshare a secret with another account. The secret is encrypted with a new key, so the account can't read future values.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sid := viper.GetString("sid")
 		if len(args) > 0 {
@@ -43,7 +44,10 @@ var secretUnshareCmd = &cobra.Command{
 			with = args[1]
 		}
 		api := getAPI()
-		if err := api.UnshareSecret(sid, with); err != nil {
+		if err := api.UnshareSecret(sid, with); err == client.ErrProposalCreated {
+			log.Print(err)
+			return
+		} else if err != nil {
 			log.Fatal(err)
 		}
 		log.Printf("successfully removed %v from shares of secret %v", with, sid)
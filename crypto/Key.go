@@ -36,6 +36,9 @@ import (
 	"github.com/trusch/storage/filter/encryption/ecdhe"
 )
 
+// ErrNoPrivateKey is returned when signing or decrypting with a key which only holds the public key
+var ErrNoPrivateKey = errors.New("no private key")
+
 type Key struct {
 	pub  *ecdsa.PublicKey
 	priv *ecdsa.PrivateKey
@@ -86,6 +89,9 @@ func (k *Key) SetPubString(pub string) error {
 		return err
 	}
 	x, y := elliptic.Unmarshal(elliptic.P256(), bs)
+	if x == nil {
+		return errors.New("malformed public key")
+	}
 	k.pub = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
 	return nil
 }
@@ -107,10 +113,16 @@ func (k *Key) GetWriter(base io.Writer) (io.WriteCloser, error) {
 }
 
 func (k *Key) GetReader(base io.Reader) (io.ReadCloser, error) {
+	if k.priv == nil {
+		return nil, ErrNoPrivateKey
+	}
 	return ecdhe.NewReader(base, k.priv)
 }
 
 func (k *Key) Sign(hash []byte) (string, error) {
+	if k.priv == nil {
+		return "", ErrNoPrivateKey
+	}
 	r, s, err := ecdsa.Sign(rand.Reader, k.priv, hash)
 	if err != nil {
 		return "", err
@@ -111,4 +111,9 @@ var _ = Describe("Key", func() {
 		err = other.Verify(hash, signature)
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("should not be possible to load a malformed public key", func() {
+		_, err := NewFromStrings("Zm9vYmFy", "")
+		Expect(err).To(HaveOccurred())
+	})
 })
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+// The shares are computed in GF(2^8) with the polynomial x^8 + x^4 + x^3 + x + 1, like in AES
+var (
+	gfExp [255]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// multiply by the generator 3
+		doubled := x << 1
+		if x&0x80 != 0 {
+			doubled ^= 0x1b
+		}
+		x ^= doubled
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
+
+// SplitSecret splits secret with Shamir's scheme into n shares, any m of which can reconstruct it.
+// The first byte of a share is its x coordinate.
+func SplitSecret(secret []byte, n, m int) ([][]byte, error) {
+	if m < 2 || m > n || n > 255 {
+		return nil, errors.New("the threshold must be between 2 and the number of shares, which can be at most 255")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+	coefficients := make([]byte, m)
+	for pos, value := range secret {
+		coefficients[0] = value
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			x, y := share[0], byte(0)
+			for i := m - 1; i >= 0; i-- {
+				y = gfMul(y, x) ^ coefficients[i]
+			}
+			share[pos+1] = y
+		}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs a secret from shares created by SplitSecret.
+// It needs at least as many shares as the threshold of the split, otherwise the result is garbage.
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least two shares are needed")
+	}
+	length := len(shares[0])
+	seen := make(map[byte]bool)
+	for _, share := range shares {
+		if len(share) != length || length < 2 {
+			return nil, errors.New("malformed share")
+		}
+		if share[0] == 0 || seen[share[0]] {
+			return nil, errors.New("duplicate or malformed share")
+		}
+		seen[share[0]] = true
+	}
+	secret := make([]byte, length-1)
+	for i, share := range shares {
+		// the lagrange basis polynomial of share evaluated at 0
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = gfMul(basis, gfDiv(other[0], other[0]^share[0]))
+			}
+		}
+		for pos := range secret {
+			secret[pos] ^= gfMul(share[pos+1], basis)
+		}
+	}
+	return secret, nil
+}
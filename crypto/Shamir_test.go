@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package crypto_test
+
+import (
+	. "github.com/trusch/passchain/crypto"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shamir", func() {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	It("should reconstruct a secret from any m of n shares", func() {
+		shares, err := SplitSecret(secret, 5, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shares).To(HaveLen(5))
+		for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+			parts := make([][]byte, 0, len(subset))
+			for _, i := range subset {
+				parts = append(parts, shares[i])
+			}
+			combined, err := CombineShares(parts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(combined).To(Equal(secret))
+		}
+	})
+
+	It("should not reconstruct a secret from less than m shares", func() {
+		shares, err := SplitSecret(secret, 5, 3)
+		Expect(err).NotTo(HaveOccurred())
+		combined, err := CombineShares(shares[:2])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(combined).NotTo(Equal(secret))
+	})
+
+	It("should reject bad parameters and shares", func() {
+		_, err := SplitSecret(secret, 2, 3)
+		Expect(err).To(HaveOccurred())
+		_, err = SplitSecret(secret, 3, 1)
+		Expect(err).To(HaveOccurred())
+		shares, err := SplitSecret(secret, 3, 2)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = CombineShares([][]byte{shares[0], shares[0]})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package gateway exposes passchain as a HTTP/JSON REST API.
+//
+// The gateway works in one of two modes: if it is constructed with an API, it acts as a
+// trusted single-user daemon which encrypts, decrypts and signs with the key of that API.
+// Such a gateway must only be reachable by its user, e.g. by binding it to localhost, and it
+// only serves requests which carry its bearer token and address it as localhost.
+// Without an API it only relays pre-signed transactions and raw queries, so all crypto
+// happens in the caller.
+package gateway
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trusch/passchain/client"
+)
+
+// Prefix is the path prefix of all routes
+const Prefix = "/v1"
+
+// ErrRelayOnly is returned by routes which need a key if the gateway has none
+var ErrRelayOnly = errors.New("the gateway has no key, it only relays pre-signed transactions")
+
+var errNotFound = errors.New("not found")
+
+// Gateway is a http.Handler which serves the REST API
+type Gateway struct {
+	// AllowRemote accepts requests for hosts other than localhost, even if the gateway has a key
+	AllowRemote bool
+
+	api   client.API
+	relay *client.BaseClient
+	mux   *http.ServeMux
+	token string
+}
+
+// New constructs a gateway which talks to the chain via transport.
+// If api is nil, only the relay routes and the public account routes are available.
+// If token is not empty, every request must carry it as bearer token.
+func New(transport client.Transport, api client.API, token string) *Gateway {
+	g := &Gateway{
+		api:   api,
+		relay: client.NewClient(transport, nil, ""),
+		mux:   http.NewServeMux(),
+		token: token,
+	}
+	g.mux.HandleFunc(Prefix+"/tx", g.handleTx)
+	g.mux.HandleFunc(Prefix+"/query", g.handleQuery)
+	g.mux.HandleFunc(Prefix+"/accounts", g.handleAccounts)
+	g.mux.HandleFunc(Prefix+"/accounts/", g.handleAccounts)
+	g.mux.HandleFunc(Prefix+"/secrets", g.trusted(g.handleSecrets))
+	g.mux.HandleFunc(Prefix+"/secrets/", g.trusted(g.handleSecrets))
+	g.mux.HandleFunc(Prefix+"/reputation", g.trusted(g.handleReputation))
+	return g
+}
+
+// NewToken generates a random bearer token
+func NewToken() (string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bs), nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := g.guard(r); err != nil {
+		writeError(w, status, err)
+		return
+	}
+	g.mux.ServeHTTP(w, r)
+}
+
+// guard rejects requests of other local users and of web pages the user visits,
+// e.g. via DNS rebinding or cross-site form posts
+func (g *Gateway) guard(r *http.Request) (int, error) {
+	if g.token != "" {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(auth[len("Bearer "):]), []byte(g.token)) != 1 {
+			return http.StatusUnauthorized, errors.New("missing or wrong bearer token")
+		}
+	}
+	if g.api != nil && !g.AllowRemote && !isLoopbackHost(r.Host) {
+		return http.StatusForbidden, errors.New("the gateway only serves requests for localhost")
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("the content type must be application/json")
+		}
+	}
+	return 0, nil
+}
+
+// isLoopbackHost returns true if host, with an optional port, is localhost or a loopback address
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// trusted wraps handlers which need the key of the gateway
+func (g *Gateway) trusted(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.api == nil {
+			writeError(w, http.StatusNotImplemented, ErrRelayOnly)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// pathSegments returns the unescaped segments of the request path after the collection name,
+// so ids which contain slashes must be escaped by the caller.
+func pathSegments(r *http.Request, collection string) ([]string, error) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), Prefix+"/"+collection)
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, nil
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = unescaped
+	}
+	return segments, nil
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeResult answers requests which change the chain
+func writeResult(w http.ResponseWriter, err error) {
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case client.ErrProposalCreated:
+		writeError(w, http.StatusAccepted, err)
+	default:
+		writeError(w, http.StatusBadRequest, err)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
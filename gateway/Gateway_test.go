@@ -0,0 +1,222 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package gateway_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	app "github.com/trusch/passchain/abci-app"
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/crypto"
+	. "github.com/trusch/passchain/gateway"
+	"github.com/trusch/passchain/state"
+	"github.com/trusch/passchain/transaction"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const token = "test-token"
+
+func do(server *httptest.Server, method, path string, body interface{}) (int, []byte) {
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		bs, err := json.Marshal(body)
+		Expect(err).NotTo(HaveOccurred())
+		reader = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	Expect(err).NotTo(HaveOccurred())
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	bs, err := ioutil.ReadAll(resp.Body)
+	Expect(err).NotTo(HaveOccurred())
+	return resp.StatusCode, bs
+}
+
+func createAccount(transport client.Transport, id string) client.API {
+	api := client.NewAPIFromClient(client.NewClient(transport, nil, id))
+	pub, priv, err := api.CreateAccount(id)
+	Expect(err).NotTo(HaveOccurred())
+	key, err := crypto.NewFromStrings(pub, priv)
+	Expect(err).NotTo(HaveOccurred())
+	return client.NewAPIFromClient(client.NewClient(transport, key, id))
+}
+
+func decodeSecret(bs []byte) *state.Secret {
+	secret := &state.Secret{}
+	Expect(json.Unmarshal(bs, secret)).To(Succeed())
+	return secret
+}
+
+var _ = Describe("Gateway", func() {
+	var (
+		transport client.Transport
+		alice     client.API
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		transport = client.NewLocalTransport(app.NewApplication())
+		alice = createAccount(transport, "alice")
+		server = httptest.NewServer(New(transport, alice, token))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create, read, update and delete secrets", func() {
+		status, body := do(server, "POST", "/v1/secrets", map[string]interface{}{
+			"id":       "team/db",
+			"value":    "s3cr3t",
+			"metadata": map[string]string{"username": "root"},
+		})
+		Expect(status).To(Equal(http.StatusCreated), string(body))
+		secret := decodeSecret(body)
+		Expect(secret.Value).To(Equal("s3cr3t"))
+		Expect(secret.Metadata.Username).To(Equal("root"))
+
+		status, body = do(server, "PUT", "/v1/secrets/team%2Fdb", map[string]interface{}{"value": "n3w"})
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		secret = decodeSecret(body)
+		Expect(secret.Value).To(Equal("n3w"))
+		Expect(secret.Metadata.Username).To(Equal("root"))
+
+		status, body = do(server, "GET", "/v1/secrets/team%2Fdb?version=1", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		Expect(decodeSecret(body).Value).To(Equal("s3cr3t"))
+
+		status, body = do(server, "GET", "/v1/secrets?prefix=team/", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		list := []*state.Secret{}
+		Expect(json.Unmarshal(body, &list)).To(Succeed())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Value).To(Equal("n3w"))
+
+		status, body = do(server, "DELETE", "/v1/secrets/team%2Fdb", nil)
+		Expect(status).To(Equal(http.StatusNoContent), string(body))
+		status, _ = do(server, "GET", "/v1/secrets/team%2Fdb", nil)
+		Expect(status).To(Equal(http.StatusNotFound))
+	})
+
+	It("should only serve json requests with the token for localhost", func() {
+		send := func(prepare func(req *http.Request)) int {
+			req, err := http.NewRequest("POST", server.URL+"/v1/secrets", bytes.NewReader([]byte(`{"id": "secret"}`)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+			prepare(req)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		Expect(send(func(req *http.Request) { req.Header.Del("Authorization") })).To(Equal(http.StatusUnauthorized))
+		Expect(send(func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") })).To(Equal(http.StatusUnauthorized))
+		Expect(send(func(req *http.Request) { req.Host = "attacker.example:46680" })).To(Equal(http.StatusForbidden))
+		Expect(send(func(req *http.Request) { req.Header.Set("Content-Type", "text/plain") })).To(Equal(http.StatusUnsupportedMediaType))
+		Expect(send(func(req *http.Request) {})).To(Equal(http.StatusCreated))
+		status, _ := do(server, "GET", "/v1/secrets/secret", nil)
+		Expect(status).To(Equal(http.StatusOK))
+	})
+
+	It("should share and unshare secrets", func() {
+		bob := createAccount(transport, "bob")
+		Expect(alice.CreateSecret("shared", "value", nil)).To(Succeed())
+
+		status, body := do(server, "POST", "/v1/secrets/shared/shares", map[string]interface{}{"account": "bob"})
+		Expect(status).To(Equal(http.StatusNoContent), string(body))
+		secret, err := bob.GetSecret("shared")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Value).To(Equal("value"))
+		Expect(secret.Roles["bob"]).To(Equal(state.RoleReader))
+
+		status, body = do(server, "DELETE", "/v1/secrets/shared/shares/bob", nil)
+		Expect(status).To(Equal(http.StatusNoContent), string(body))
+		status, body = do(server, "GET", "/v1/secrets/shared", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		Expect(decodeSecret(body).Shares).NotTo(HaveKey("bob"))
+
+		status, body = do(server, "POST", "/v1/reputation", map[string]interface{}{"receiver": "bob", "value": 1})
+		Expect(status).To(Equal(http.StatusNoContent), string(body))
+		status, body = do(server, "GET", "/v1/accounts/bob", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		account := &state.Account{}
+		Expect(json.Unmarshal(body, account)).To(Succeed())
+		Expect(account.Reputation["alice"]).To(Equal(1))
+	})
+
+	It("should relay pre-signed transactions and raw queries", func() {
+		relay := httptest.NewServer(New(transport, nil, ""))
+		defer relay.Close()
+
+		key, err := crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		tx := transaction.New(transaction.AccountAdd, &transaction.AccountAddData{
+			Account: &state.Account{ID: "carol", PubKey: key.GetPubString()},
+		})
+		_, err = tx.ProofOfWork(context.Background(), transaction.DefaultProofOfWorkCost)
+		Expect(err).NotTo(HaveOccurred())
+		bs, err := tx.ToBytes()
+		Expect(err).NotTo(HaveOccurred())
+		status, body := do(relay, "POST", "/v1/tx", bs)
+		Expect(status).To(Equal(http.StatusBadRequest), string(body))
+		Expect(tx.Sign(key)).To(Succeed())
+		bs, err = tx.ToBytes()
+		Expect(err).NotTo(HaveOccurred())
+		status, body = do(relay, "POST", "/v1/tx", bs)
+		Expect(status).To(Equal(http.StatusNoContent), string(body))
+		status, body = do(relay, "POST", "/v1/tx", bs)
+		Expect(status).To(Equal(http.StatusBadRequest), string(body))
+
+		status, body = do(relay, "GET", "/v1/query?path=/account&data=carol", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		account := &state.Account{}
+		Expect(json.Unmarshal(body, account)).To(Succeed())
+		Expect(account.PubKey).To(Equal(key.GetPubString()))
+
+		status, body = do(relay, "GET", "/v1/accounts", nil)
+		Expect(status).To(Equal(http.StatusOK), string(body))
+		accounts := []*state.Account{}
+		Expect(json.Unmarshal(body, &accounts)).To(Succeed())
+		Expect(accounts).To(HaveLen(2))
+
+		status, _ = do(relay, "GET", "/v1/secrets", nil)
+		Expect(status).To(Equal(http.StatusNotImplemented))
+		status, _ = do(relay, "DELETE", "/v1/accounts/carol", nil)
+		Expect(status).To(Equal(http.StatusNotImplemented))
+	})
+})
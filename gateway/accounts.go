@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/trusch/passchain/state"
+)
+
+// handleAccounts serves /v1/accounts and /v1/accounts/{id}, accounts are public so
+// only deleting one needs the key of the gateway
+func (g *Gateway) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	segments, err := pathSegments(r, "accounts")
+	if err != nil || len(segments) > 1 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	if len(segments) == 0 {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		g.listAccounts(w, r)
+		return
+	}
+	id := segments[0]
+	switch r.Method {
+	case http.MethodGet:
+		account, err := g.relay.GetAccount(id)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, account)
+	case http.MethodDelete:
+		g.trusted(func(w http.ResponseWriter, r *http.Request) {
+			writeResult(w, g.api.DeleteAccount(id))
+		})(w, r)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (g *Gateway) listAccounts(w http.ResponseWriter, r *http.Request) {
+	opts := &state.ListOptions{Prefix: r.URL.Query().Get("prefix")}
+	accounts := make([]*state.Account, 0)
+	for {
+		page, err := g.relay.ListAccounts(opts)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		accounts = append(accounts, page.Accounts...)
+		if page.Next == "" {
+			break
+		}
+		opts.Start = page.Next
+	}
+	writeJSON(w, http.StatusOK, accounts)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package gateway
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/trusch/passchain/transaction"
+)
+
+// handleTx broadcasts a transaction which is already signed and carries a proof of work.
+// The body is forwarded unchanged, so the signature stays valid.
+func (g *Gateway) handleTx(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	defer r.Body.Close()
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	tx := &transaction.Transaction{}
+	if err = tx.FromBytes(bs); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if tx.Signature == "" {
+		writeError(w, http.StatusBadRequest, errors.New("transaction is not signed"))
+		return
+	}
+	writeResult(w, g.relay.BroadcastTx(bs))
+}
+
+// handleQuery runs a raw abci query, e.g. GET /v1/query?path=/secret&data=my-secret
+func (g *Gateway) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("path is missing"))
+		return
+	}
+	value, err := g.relay.Query(path, []byte(r.URL.Query().Get("data")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(value) == 0 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
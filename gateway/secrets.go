@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/trusch/passchain/client"
+	"github.com/trusch/passchain/state"
+)
+
+// secretRequest is the body of requests which create or update a secret
+type secretRequest struct {
+	ID                string                `json:"id"`
+	Value             string                `json:"value"`
+	Metadata          *state.SecretMetadata `json:"metadata"`
+	ExpiresAt         *uint64               `json:"expiresAt"`
+	RotateEvery       *uint64               `json:"rotateEvery"`
+	Threshold         int                   `json:"threshold"`
+	Members           []string              `json:"members"`
+	RequiredApprovals int                   `json:"requiredApprovals"`
+}
+
+// shareRequest is the body of requests which share a secret
+type shareRequest struct {
+	Account string     `json:"account"`
+	Role    state.Role `json:"role"`
+}
+
+// handleSecrets serves /v1/secrets, /v1/secrets/{id}, /v1/secrets/{id}/shares
+// and /v1/secrets/{id}/shares/{account}
+func (g *Gateway) handleSecrets(w http.ResponseWriter, r *http.Request) {
+	segments, err := pathSegments(r, "secrets")
+	if err != nil {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		g.listSecrets(w, r)
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		g.createSecret(w, r)
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		g.getSecret(w, r, segments[0])
+	case len(segments) == 1 && r.Method == http.MethodPut:
+		g.updateSecret(w, r, segments[0])
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		writeResult(w, g.api.DeleteSecret(segments[0]))
+	case len(segments) == 2 && segments[1] == "shares" && r.Method == http.MethodPost:
+		g.shareSecret(w, r, segments[0])
+	case len(segments) == 3 && segments[1] == "shares" && r.Method == http.MethodDelete:
+		writeResult(w, g.api.UnshareSecret(segments[0], segments[2]))
+	case len(segments) > 3 || len(segments) >= 2 && segments[1] != "shares":
+		writeError(w, http.StatusNotFound, errNotFound)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (g *Gateway) listSecrets(w http.ResponseWriter, r *http.Request) {
+	var (
+		secrets []*state.Secret
+		err     error
+	)
+	prefix := r.URL.Query().Get("prefix")
+	if shared, _ := strconv.ParseBool(r.URL.Query().Get("shared")); shared {
+		secrets, err = g.api.ListSharedSecrets(prefix)
+	} else {
+		secrets, err = g.api.ListSecrets(prefix)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+func (g *Gateway) createSecret(w http.ResponseWriter, r *http.Request) {
+	req := &secretRequest{}
+	if err := readJSON(r, req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("id is missing"))
+		return
+	}
+	opts := &client.SecretOptions{
+		Metadata:          req.Metadata,
+		ExpiresAt:         req.ExpiresAt,
+		RotateEvery:       req.RotateEvery,
+		Threshold:         req.Threshold,
+		Members:           req.Members,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+	if err := g.api.CreateSecret(req.ID, req.Value, opts); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	g.writeCurrentSecret(w, http.StatusCreated, req.ID)
+}
+
+func (g *Gateway) getSecret(w http.ResponseWriter, r *http.Request, id string) {
+	v := r.URL.Query().Get("version")
+	if v == "" {
+		g.writeCurrentSecret(w, http.StatusOK, id)
+		return
+	}
+	version, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	secret, err := g.api.GetSecretVersion(id, version)
+	writeSecret(w, http.StatusOK, secret, err)
+}
+
+// writeCurrentSecret answers with the current, decrypted version of a secret
+func (g *Gateway) writeCurrentSecret(w http.ResponseWriter, status int, id string) {
+	secret, err := g.api.GetSecret(id)
+	writeSecret(w, status, secret, err)
+}
+
+func writeSecret(w http.ResponseWriter, status int, secret *state.Secret, err error) {
+	switch {
+	case err == client.ErrSecretLocked:
+		writeError(w, http.StatusLocked, err)
+	case err != nil:
+		writeError(w, http.StatusNotFound, err)
+	default:
+		writeJSON(w, status, secret)
+	}
+}
+
+// updateSecret changes the value and the attributes which are present in the body
+func (g *Gateway) updateSecret(w http.ResponseWriter, r *http.Request, id string) {
+	req := &secretRequest{}
+	if err := readJSON(r, req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := &client.SecretOptions{
+		Metadata:    req.Metadata,
+		ExpiresAt:   req.ExpiresAt,
+		RotateEvery: req.RotateEvery,
+	}
+	if err := g.api.UpdateSecret(id, req.Value, opts); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	g.writeCurrentSecret(w, http.StatusOK, id)
+}
+
+func (g *Gateway) shareSecret(w http.ResponseWriter, r *http.Request, id string) {
+	req := &shareRequest{}
+	if err := readJSON(r, req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Account == "" {
+		writeError(w, http.StatusBadRequest, errors.New("account is missing"))
+		return
+	}
+	if req.Role == "" {
+		req.Role = state.RoleReader
+	}
+	writeResult(w, g.api.ShareSecret(id, req.Account, req.Role))
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/trusch/passchain/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Default scrypt parameters for newly written key files
+var (
+	ScryptN = 1 << 15
+	ScryptR = 8
+	ScryptP = 1
+)
+
+const (
+	keyFileSuffix = ".json"
+	currentFile   = "current"
+)
+
+var (
+	// ErrNoKey is returned if the requested key does not exist
+	ErrNoKey = errors.New("no such key")
+	// ErrBadPassphrase is returned if a key file can not be decrypted
+	ErrBadPassphrase = errors.New("wrong passphrase or corrupted key file")
+)
+
+// KDFParams are the scrypt parameters used to derive the encryption key from the passphrase
+type KDFParams struct {
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// KeyFile is the on-disk representation of an account key.
+// The private key is encrypted with AES-GCM, the public key is used as additional data.
+type KeyFile struct {
+	Name       string    `json:"name"`
+	ID         string    `json:"id"`
+	Endpoint   string    `json:"endpoint,omitempty"`
+	PubKey     string    `json:"pubkey"`
+	KDF        KDFParams `json:"kdf"`
+	Nonce      string    `json:"nonce"`
+	Ciphertext string    `json:"ciphertext"`
+}
+
+// Keystore manages key files in a directory
+type Keystore struct {
+	dir string
+}
+
+// DefaultDir returns ~/.passchain/keys
+func DefaultDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".passchain", "keys"), nil
+}
+
+// New returns a keystore which lives in dir
+func New(dir string) *Keystore {
+	return &Keystore{dir}
+}
+
+// Encrypt creates a key file for the given key
+func Encrypt(name, id, endpoint string, key *crypto.Key, passphrase []byte) (*KeyFile, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	kf := &KeyFile{
+		Name:     name,
+		ID:       id,
+		Endpoint: endpoint,
+		PubKey:   key.GetPubString(),
+		KDF: KDFParams{
+			Salt: base64.StdEncoding.EncodeToString(salt),
+			N:    ScryptN,
+			R:    ScryptR,
+			P:    ScryptP,
+		},
+	}
+	aead, err := kf.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	cipherText := aead.Seal(nil, nonce, []byte(key.GetPrivString()), []byte(kf.PubKey))
+	kf.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	kf.Ciphertext = base64.StdEncoding.EncodeToString(cipherText)
+	return kf, nil
+}
+
+// Decrypt returns the key stored in the key file
+func (kf *KeyFile) Decrypt(passphrase []byte) (*crypto.Key, error) {
+	aead, err := kf.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(kf.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("malformed nonce")
+	}
+	cipherText, err := base64.StdEncoding.DecodeString(kf.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := aead.Open(nil, nonce, cipherText, []byte(kf.PubKey))
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+	return crypto.NewFromStrings(kf.PubKey, string(priv))
+}
+
+func (kf *KeyFile) aead(passphrase []byte) (cipher.AEAD, error) {
+	salt, err := base64.StdEncoding.DecodeString(kf.KDF.Salt)
+	if err != nil {
+		return nil, err
+	}
+	dk, err := scrypt.Key(passphrase, salt, kf.KDF.N, kf.KDF.R, kf.KDF.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Save writes a key file to the keystore, existing keys are not overwritten
+func (ks *Keystore) Save(kf *KeyFile) error {
+	if err := CheckName(kf.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ks.dir, 0700); err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(ks.path(kf.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return errors.New("key " + kf.Name + " already exists")
+		}
+		return err
+	}
+	if _, err = f.Write(bs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a key file from the keystore
+func (ks *Keystore) Load(name string) (*KeyFile, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	bs, err := ioutil.ReadFile(ks.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoKey
+		}
+		return nil, err
+	}
+	kf := &KeyFile{}
+	if err = json.Unmarshal(bs, kf); err != nil {
+		return nil, err
+	}
+	kf.Name = name
+	return kf, nil
+}
+
+// List returns all key files sorted by name
+func (ks *Keystore) List() ([]*KeyFile, error) {
+	infos, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), keyFileSuffix) {
+			names = append(names, strings.TrimSuffix(info.Name(), keyFileSuffix))
+		}
+	}
+	sort.Strings(names)
+	res := make([]*KeyFile, 0, len(names))
+	for _, name := range names {
+		kf, err := ks.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, kf)
+	}
+	return res, nil
+}
+
+// Use marks a key as the one to use by default
+func (ks *Keystore) Use(name string) error {
+	if _, err := ks.Load(name); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ks.dir, currentFile), []byte(name), 0600)
+}
+
+// Current returns the name of the default key or "" if there is none
+func (ks *Keystore) Current() (string, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(ks.dir, currentFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.dir, name+keyFileSuffix)
+}
+
+// CheckName returns an error if name can't be used as the name of a key file
+func CheckName(name string) error {
+	if name == "" || name == currentFile || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return errors.New("invalid key name")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package keystore_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/trusch/passchain/crypto"
+	. "github.com/trusch/passchain/keystore"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keystore", func() {
+	var (
+		dir string
+		ks  *Keystore
+		key *crypto.Key
+	)
+
+	BeforeEach(func() {
+		ScryptN = 1 << 10
+		var err error
+		dir, err = ioutil.TempDir("", "passchain-keystore")
+		Expect(err).NotTo(HaveOccurred())
+		ks = New(dir)
+		key, err = crypto.CreateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should encrypt and decrypt a key", func() {
+		kf, err := Encrypt("alice", "alice", "http://localhost:46657", key, []byte("secret"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kf.Ciphertext).NotTo(ContainSubstring(key.GetPrivString()))
+		Expect(ks.Save(kf)).To(Succeed())
+		loaded, err := ks.Load("alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.ID).To(Equal("alice"))
+		Expect(loaded.Endpoint).To(Equal("http://localhost:46657"))
+		decrypted, err := loaded.Decrypt([]byte("secret"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypted.GetPrivString()).To(Equal(key.GetPrivString()))
+		_, err = loaded.Decrypt([]byte("wrong"))
+		Expect(err).To(Equal(ErrBadPassphrase))
+	})
+
+	It("should list keys and remember the current one", func() {
+		for _, name := range []string{"bob", "alice"} {
+			kf, err := Encrypt(name, name, "", key, []byte("secret"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ks.Save(kf)).To(Succeed())
+		}
+		kf, _ := Encrypt("alice", "alice", "", key, []byte("secret"))
+		Expect(ks.Save(kf)).NotTo(Succeed())
+		list, err := ks.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Name).To(Equal("alice"))
+		Expect(list[1].Name).To(Equal("bob"))
+		current, err := ks.Current()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current).To(BeEmpty())
+		Expect(ks.Use("bob")).To(Succeed())
+		current, err = ks.Current()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current).To(Equal("bob"))
+		Expect(ks.Use("carol")).To(Equal(ErrNoKey))
+		_, err = ks.Load("../bob")
+		Expect(err).To(HaveOccurred())
+		Expect(CheckName("../bob")).NotTo(Succeed())
+		Expect(CheckName(".hidden")).NotTo(Succeed())
+		Expect(CheckName("bob")).To(Succeed())
+	})
+})
@@ -32,6 +32,52 @@ type Account struct {
 	ID         string         `json:"id" mapstructure:"id"`
 	PubKey     string         `json:"pubkey" mapstructure:"pubkey"`
 	Reputation map[string]int `json:"reputation" mapstructure:"reputation"`
+	Sequence   uint64         `json:"sequence" mapstructure:"sequence"`
+	// Guardians are accounts which can approve a key rotation
+	Guardians []string `json:"guardians,omitempty" mapstructure:"guardians"`
+	// GuardianThreshold is the number of guardian approvals a key rotation or recovery needs.
+	// 0 means that no approvals are needed for rotations and that the account can't be recovered.
+	GuardianThreshold int `json:"guardianThreshold,omitempty" mapstructure:"guardianThreshold"`
+	// RecoveryDelay is the number of seconds between the approval of a recovery and the
+	// installation of the new key, 0 means DefaultRecoveryDelay
+	RecoveryDelay uint64 `json:"recoveryDelay,omitempty" mapstructure:"recoveryDelay"`
+	// Recovery is the pending recovery of the account, if any
+	Recovery *AccountRecovery `json:"recovery,omitempty" mapstructure:"recovery"`
+	// Window is the budget window of the last transaction of the account
+	Window uint64 `json:"window,omitempty" mapstructure:"window"`
+	// WindowTxs is the number of transactions the account sent in that window
+	WindowTxs int `json:"windowTxs,omitempty" mapstructure:"windowTxs"`
+}
+
+// ValidateGuardians checks that the guardians exist and the threshold can be reached
+func (s *State) ValidateGuardians(account *Account) error {
+	seen := make(map[string]bool)
+	for _, guardian := range account.Guardians {
+		if guardian == account.ID {
+			return errors.New("an account can't be its own guardian")
+		}
+		if seen[guardian] {
+			return errors.New("duplicate guardian " + guardian)
+		}
+		seen[guardian] = true
+		if !s.HasAccount(guardian) {
+			return errors.New("guardian " + guardian + " doesn't exist")
+		}
+	}
+	if account.GuardianThreshold < 0 || account.GuardianThreshold > len(account.Guardians) {
+		return errors.New("guardian threshold must be between 0 and the number of guardians")
+	}
+	return nil
+}
+
+// IsGuardian returns true if id is a guardian of the account
+func (account *Account) IsGuardian(id string) bool {
+	for _, guardian := range account.Guardians {
+		if guardian == id {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *State) AddAccount(account *Account) error {
@@ -63,14 +109,33 @@ func (s *State) GetAccount(id string) (*Account, error) {
 	return acc, json.Unmarshal(bs, acc)
 }
 
+// DeleteAccount removes an account but remembers its sequence, so transactions signed before the
+// deletion can't be replayed if the id is registered again with the same key
 func (s *State) DeleteAccount(id string) error {
-	_, removed := s.Tree.Remove([]byte(accountPrefix + id))
-	if !removed {
-		return errors.New("no such account")
+	acc, err := s.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	bs, err := json.Marshal(acc.Sequence)
+	if err != nil {
+		return err
 	}
+	s.Tree.Set([]byte(deletedPrefix+id), bs)
+	s.Tree.Remove([]byte(recoveryPrefix + id))
+	s.Tree.Remove([]byte(accountPrefix + id))
 	return nil
 }
 
+// DeletedSequence returns the sequence an account had when it was deleted, 0 if it never was
+func (s *State) DeletedSequence(id string) (uint64, error) {
+	_, bs, exists := s.Tree.Get([]byte(deletedPrefix + id))
+	if !exists {
+		return 0, nil
+	}
+	var sequence uint64
+	return sequence, json.Unmarshal(bs, &sequence)
+}
+
 func (s *State) GetAccountPubKey(id string) (*crypto.Key, error) {
 	acc, err := s.GetAccount(id)
 	if err != nil {
@@ -79,19 +144,45 @@ func (s *State) GetAccountPubKey(id string) (*crypto.Key, error) {
 	return crypto.NewFromStrings(acc.PubKey, "")
 }
 
-func (s *State) ListAccounts() (result []*Account, err error) {
-	start := accountPrefix
-	end := start[:len(start)-1]
-	end = end + string(start[len(start)-1]+1)
-	result = make([]*Account, 0)
-	s.Tree.IterateRange([]byte(start), []byte(end), true, func(key []byte, value []byte) bool {
+// AccountPage is a page of an account list
+type AccountPage struct {
+	Accounts []*Account `json:"accounts"`
+	// Next is the start of the next page, it is empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// ListAccounts returns a page of the accounts matching opts
+func (s *State) ListAccounts(opts *ListOptions) (*AccountPage, error) {
+	page := &AccountPage{Accounts: make([]*Account, 0)}
+	next, err := s.iterateList(accountPrefix, opts, func(id string, value []byte) error {
 		acc := &Account{}
-		err = json.Unmarshal(value, acc)
-		if err != nil {
-			return true
+		if err := json.Unmarshal(value, acc); err != nil {
+			return err
 		}
-		result = append(result, acc)
-		return false
+		page.Accounts = append(page.Accounts, acc)
+		return nil
 	})
-	return
+	page.Next = next
+	return page, err
+}
+
+// IncrementSequence increments the sequence number of an account after it sent a transaction
+// and counts the transaction against its budget
+func (s *State) IncrementSequence(id string) error {
+	acc, err := s.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	acc.Sequence++
+	params, err := s.GetParams()
+	if err != nil {
+		return err
+	}
+	window := params.WindowOf(s.Height)
+	if acc.Window != window {
+		acc.Window = window
+		acc.WindowTxs = 0
+	}
+	acc.WindowTxs++
+	return s.SetAccount(acc)
 }
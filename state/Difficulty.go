@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+// MaxReputationDiscount is the number of bits reputation can lower the proof of work cost at most,
+// so accounts which give each other reputation can't make their transactions free
+const MaxReputationDiscount = 4
+
+// Difficulty describes the proof of work cost an account currently needs
+type Difficulty struct {
+	Account         string `json:"account" mapstructure:"account"`
+	ProofOfWorkCost byte   `json:"proofOfWorkCost" mapstructure:"proofOfWorkCost"`
+	// WindowTxs is the number of transactions the account sent in the current budget window
+	WindowTxs int `json:"windowTxs" mapstructure:"windowTxs"`
+	// TxBudget is the number of transactions per window which don't raise the cost, 0 means unlimited
+	TxBudget   int `json:"txBudget" mapstructure:"txBudget"`
+	Reputation int `json:"reputation" mapstructure:"reputation"`
+}
+
+// TotalReputation returns the sum of the reputation other accounts gave the account
+func (account *Account) TotalReputation() int {
+	total := 0
+	for giver, value := range account.Reputation {
+		if giver != account.ID {
+			total += value
+		}
+	}
+	return total
+}
+
+// WindowOf returns the budget window of a block height
+func (params *Params) WindowOf(height uint64) uint64 {
+	blocks := params.BudgetBlocks
+	if blocks == 0 {
+		blocks = DefaultBudgetBlocks
+	}
+	return height / blocks
+}
+
+// CostFor returns the proof of work cost the next transaction of an account needs at a block height.
+// Reputation lowers the cost by up to MaxReputationDiscount bits, but not below MinProofOfWorkCost.
+// Every transaction over the budget raises it by one bit.
+func (params *Params) CostFor(account *Account, height uint64) byte {
+	cost := int(params.ProofOfWorkCost)
+	if params.ReputationPerBit > 0 {
+		if reputation := account.TotalReputation(); reputation > 0 {
+			discount := reputation / params.ReputationPerBit
+			if discount > MaxReputationDiscount {
+				discount = MaxReputationDiscount
+			}
+			cost -= discount
+			if cost < int(params.MinProofOfWorkCost) {
+				cost = int(params.MinProofOfWorkCost)
+			}
+		}
+	}
+	if params.TxBudget > 0 && account.Window == params.WindowOf(height) && account.WindowTxs >= params.TxBudget {
+		cost += account.WindowTxs - params.TxBudget + 1
+	}
+	if cost > MaxProofOfWorkCost {
+		cost = MaxProofOfWorkCost
+	}
+	return byte(cost)
+}
+
+// GetDifficulty returns the proof of work cost the next transaction of an account needs.
+// Accounts which don't exist yet get the cost of a new account.
+func (s *State) GetDifficulty(id string) (*Difficulty, error) {
+	params, err := s.GetParams()
+	if err != nil {
+		return nil, err
+	}
+	acc := &Account{ID: id}
+	if s.HasAccount(id) {
+		if acc, err = s.GetAccount(id); err != nil {
+			return nil, err
+		}
+	}
+	difficulty := &Difficulty{
+		Account:         id,
+		ProofOfWorkCost: params.CostFor(acc, s.Height),
+		TxBudget:        params.TxBudget,
+		Reputation:      acc.TotalReputation(),
+	}
+	if acc.Window == params.WindowOf(s.Height) {
+		difficulty.WindowTxs = acc.WindowTxs
+	}
+	return difficulty, nil
+}
+
+// ProofOfWorkCostFor returns the proof of work cost the next transaction of an account needs
+func (s *State) ProofOfWorkCostFor(id string) byte {
+	difficulty, err := s.GetDifficulty(id)
+	if err != nil {
+		return s.ProofOfWorkCost()
+	}
+	return difficulty.ProofOfWorkCost
+}
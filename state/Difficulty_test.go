@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"log"
+	"testing"
+
+	"github.com/tendermint/merkleeyes/iavl"
+)
+
+func TestDifficulty(t *testing.T) {
+	s := NewStateFromTree(iavl.NewIAVLTree(0, nil))
+	s.Genesis = &Genesis{Params: &Params{
+		ProofOfWorkCost:    10,
+		TxBudget:           2,
+		BudgetBlocks:       10,
+		ReputationPerBit:   2,
+		MinProofOfWorkCost: 6,
+	}}
+	if err := s.AddAccount(&Account{ID: "alice"}); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	s.Height = 5
+	for i, expected := range []byte{10, 10, 11, 12} {
+		if cost := s.ProofOfWorkCostFor("alice"); cost != expected {
+			log.Printf("wrong cost for transaction %v: %v", i, cost)
+			t.Fail()
+		}
+		if err := s.IncrementSequence("alice"); err != nil {
+			log.Print(err)
+			t.Fail()
+		}
+	}
+	s.Height = 10
+	if cost := s.ProofOfWorkCostFor("alice"); cost != 10 {
+		log.Printf("budget wasn't renewed: %v", cost)
+		t.Fail()
+	}
+	acc, _ := s.GetAccount("alice")
+	acc.Reputation = map[string]int{"bob": 3, "carol": 3}
+	s.SetAccount(acc)
+	if cost := s.ProofOfWorkCostFor("alice"); cost != 7 {
+		log.Printf("wrong reputation discount: %v", cost)
+		t.Fail()
+	}
+	acc.Reputation["dave"] = 3
+	acc.Reputation["eve"] = 3
+	s.SetAccount(acc)
+	if cost := s.ProofOfWorkCostFor("alice"); cost != 6 {
+		log.Printf("discount went below the minimum: %v", cost)
+		t.Fail()
+	}
+	params, _ := s.GetParams()
+	params.MinProofOfWorkCost = 0
+	s.SetParams(params)
+	acc.Reputation["frank"] = 3
+	acc.Reputation["alice"] = 3
+	s.SetAccount(acc)
+	if cost := s.ProofOfWorkCostFor("alice"); cost != 10-MaxReputationDiscount {
+		log.Printf("discount wasn't capped: %v", cost)
+		t.Fail()
+	}
+	if acc.TotalReputation() != 15 {
+		log.Print("own reputation was counted")
+		t.Fail()
+	}
+	if cost := s.ProofOfWorkCostFor("new"); cost != 10 {
+		log.Printf("wrong cost for a new account: %v", cost)
+		t.Fail()
+	}
+}
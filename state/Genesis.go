@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/trusch/passchain/crypto"
+)
+
+// Genesis is the initial state of the app, it is read from the app_options of the tendermint genesis file
+type Genesis struct {
+	// Params replace the default params, their admins must be among the genesis accounts
+	Params *Params `json:"params,omitempty" mapstructure:"params"`
+	// Accounts are created with the chain, so nobody else can register their ids first
+	Accounts []*Account `json:"accounts,omitempty" mapstructure:"accounts"`
+}
+
+// ReadGenesis reads the genesis of the app from a tendermint genesis file
+func ReadGenesis(file string) (*Genesis, error) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	doc := struct {
+		AppOptions *Genesis `json:"app_options"`
+	}{}
+	if err = json.Unmarshal(bs, &doc); err != nil {
+		return nil, err
+	}
+	if doc.AppOptions == nil {
+		return &Genesis{}, nil
+	}
+	return doc.AppOptions, doc.AppOptions.Validate()
+}
+
+// Validate checks that the params are valid and their admins are genesis accounts
+func (genesis *Genesis) Validate() error {
+	accounts := make(map[string]bool)
+	for _, account := range genesis.Accounts {
+		if account.ID == "" || accounts[account.ID] {
+			return errors.New("genesis accounts need distinct ids")
+		}
+		if _, err := crypto.NewFromStrings(account.PubKey, ""); err != nil {
+			return errors.New("bad pubkey of genesis account " + account.ID + ": " + err.Error())
+		}
+		accounts[account.ID] = true
+	}
+	if genesis.Params == nil {
+		return nil
+	}
+	if err := genesis.Params.Validate(); err != nil {
+		return err
+	}
+	for _, admin := range genesis.Params.Admins {
+		if !accounts[admin] {
+			return errors.New("admin " + admin + " is no genesis account")
+		}
+	}
+	return nil
+}
+
+// EnsureGenesis stores the genesis params and accounts if the state has no params yet.
+// It must be called at the same point of the chain on every node, e.g. in BeginBlock.
+func (s *State) EnsureGenesis() error {
+	if s.Tree.Has([]byte(paramsKey)) {
+		return nil
+	}
+	params, err := s.GetParams()
+	if err != nil {
+		return err
+	}
+	if s.Genesis != nil {
+		for _, account := range s.Genesis.Accounts {
+			if err = s.AddAccount(&Account{ID: account.ID, PubKey: account.PubKey}); err != nil {
+				return errors.New("genesis account " + account.ID + ": " + err.Error())
+			}
+		}
+	}
+	return s.SetParams(params)
+}
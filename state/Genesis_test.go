@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/trusch/passchain/crypto"
+)
+
+func TestGenesis(t *testing.T) {
+	key, err := crypto.CreateKeyPair()
+	if err != nil {
+		log.Print(err)
+		t.FailNow()
+	}
+	file, err := ioutil.TempFile("", "genesis")
+	if err != nil {
+		log.Print(err)
+		t.FailNow()
+	}
+	defer os.Remove(file.Name())
+	doc := `{"chain_id": "test", "app_options": {
+		"params": {"proofOfWorkCost": 8, "admins": ["alice"]},
+		"accounts": [{"id": "alice", "pubkey": "` + key.GetPubString() + `"}]
+	}}`
+	if _, err = file.WriteString(doc); err != nil {
+		log.Print(err)
+		t.FailNow()
+	}
+	file.Close()
+	genesis, err := ReadGenesis(file.Name())
+	if err != nil || genesis.Params.ProofOfWorkCost != 8 || len(genesis.Accounts) != 1 {
+		log.Printf("wrong genesis: %+v, %v", genesis, err)
+		t.Fail()
+	}
+	for _, invalid := range []*Genesis{
+		{Params: &Params{Admins: []string{"alice"}}},
+		{Accounts: []*Account{{ID: "alice", PubKey: "garbage"}}},
+		{Accounts: []*Account{{ID: "alice", PubKey: key.GetPubString()}, {ID: "alice", PubKey: key.GetPubString()}}},
+	} {
+		if invalid.Validate() == nil {
+			log.Printf("invalid genesis passed: %+v", invalid)
+			t.Fail()
+		}
+	}
+}
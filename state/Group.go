@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Group is an account whose private key is shared with its members.
+// The group has an account with the same ID, so secrets can be shared with it.
+type Group struct {
+	ID string `json:"id" mapstructure:"id"`
+	// Keys holds the private key of the group encrypted for each member
+	Keys map[string]string `json:"keys" mapstructure:"keys"`
+	// Admins can add and remove members
+	Admins map[string]bool `json:"admins" mapstructure:"admins"`
+}
+
+// GroupRef is an entry of the index of groups an account is member of
+type GroupRef struct {
+	Account string `json:"account"`
+	Group   string `json:"group"`
+}
+
+// GroupPage is a page of a group list
+type GroupPage struct {
+	Groups []*Group `json:"groups"`
+	// Next is the start of the next page, it is empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// IsMember returns true if account has a key of the group
+func (group *Group) IsMember(account string) bool {
+	_, ok := group.Keys[account]
+	return ok
+}
+
+// IsAdmin returns true if account is an admin member of the group
+func (group *Group) IsAdmin(account string) bool {
+	return group.IsMember(account) && group.Admins[account]
+}
+
+// CountAdmins returns the number of admin members
+func (group *Group) CountAdmins() int {
+	count := 0
+	for account := range group.Keys {
+		if group.IsAdmin(account) {
+			count++
+		}
+	}
+	return count
+}
+
+// AddGroup stores a new group together with its account, unless the account exists already
+func (s *State) AddGroup(group *Group, pubKey string) error {
+	if s.HasGroup(group.ID) {
+		return errors.New("group already exists")
+	}
+	if !s.HasAccount(group.ID) {
+		if err := s.AddAccount(&Account{ID: group.ID, PubKey: pubKey}); err != nil {
+			return err
+		}
+	}
+	return s.SetGroup(group)
+}
+
+func (s *State) SetGroup(group *Group) error {
+	bs, err := json.Marshal(group)
+	if err != nil {
+		return err
+	}
+	var old *Group
+	if s.HasGroup(group.ID) {
+		if old, err = s.GetGroup(group.ID); err != nil {
+			return err
+		}
+	}
+	s.Tree.Set([]byte(groupPrefix+group.ID), bs)
+	return s.updateGroupIndex(old, group)
+}
+
+func (s *State) HasGroup(id string) bool {
+	return s.Tree.Has([]byte(groupPrefix + id))
+}
+
+func (s *State) GetGroup(id string) (*Group, error) {
+	_, bs, exists := s.Tree.Get([]byte(groupPrefix + id))
+	if !exists {
+		return nil, errors.New("no such group")
+	}
+	group := &Group{Keys: make(map[string]string), Admins: make(map[string]bool)}
+	return group, json.Unmarshal(bs, group)
+}
+
+func (s *State) DeleteGroup(id string) error {
+	old, err := s.GetGroup(id)
+	if err != nil {
+		return err
+	}
+	s.Tree.Remove([]byte(groupPrefix + id))
+	return s.updateGroupIndex(old, nil)
+}
+
+func groupIndexKey(account, group string) []byte {
+	return []byte(groupIndexPrefix + account + "::" + group)
+}
+
+// updateGroupIndex updates the index entries of a group which changed from old to group.
+// old is nil for new groups and group is nil for deleted ones.
+func (s *State) updateGroupIndex(old, group *Group) error {
+	// the tree must be changed in the same order on every node
+	if old != nil {
+		for _, account := range sortedAccounts(old.Keys) {
+			if group == nil || !group.IsMember(account) {
+				s.Tree.Remove(groupIndexKey(account, old.ID))
+			}
+		}
+	}
+	if group == nil {
+		return nil
+	}
+	for _, account := range sortedAccounts(group.Keys) {
+		bs, err := json.Marshal(&GroupRef{account, group.ID})
+		if err != nil {
+			return err
+		}
+		s.Tree.Set(groupIndexKey(account, group.ID), bs)
+	}
+	return nil
+}
+
+// ListGroups returns a page of the groups matching opts
+func (s *State) ListGroups(opts *ListOptions) (*GroupPage, error) {
+	page := &GroupPage{Groups: make([]*Group, 0)}
+	next, err := s.iterateList(groupPrefix, opts, func(id string, value []byte) error {
+		group := &Group{}
+		if err := json.Unmarshal(value, group); err != nil {
+			return err
+		}
+		page.Groups = append(page.Groups, group)
+		return nil
+	})
+	page.Next = next
+	return page, err
+}
+
+// GroupsOf returns the groups the account is member of
+func (s *State) GroupsOf(account string) ([]*Group, error) {
+	ids := make([]string, 0)
+	var err error
+	start := string(groupIndexKey(account, ""))
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		ref := &GroupRef{}
+		if err = json.Unmarshal(value, ref); err != nil {
+			return true
+		}
+		// skip the entries of accounts whose id starts with account + "::"
+		if ref.Account == account {
+			ids = append(ids, ref.Group)
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Group, 0, len(ids))
+	for _, id := range ids {
+		group, err := s.GetGroup(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, group)
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"log"
+	"testing"
+
+	"github.com/tendermint/merkleeyes/iavl"
+)
+
+func TestGroupsOf(t *testing.T) {
+	s := NewStateFromTree(iavl.NewIAVLTree(0, nil))
+	for _, group := range []*Group{
+		{ID: "ops", Keys: map[string]string{"alice": "key", "bob": "key"}},
+		{ID: "dev", Keys: map[string]string{"alice": "key"}},
+		{ID: "other", Keys: map[string]string{"alice::x": "key"}},
+	} {
+		if err := s.AddGroup(group, ""); err != nil {
+			log.Print(err)
+			t.Fail()
+		}
+	}
+	count := func(account string) int {
+		groups, err := s.GroupsOf(account)
+		if err != nil {
+			log.Print(err)
+			t.Fail()
+		}
+		return len(groups)
+	}
+	if count("alice") != 2 || count("bob") != 1 {
+		log.Print("wrong groups")
+		t.Fail()
+	}
+	group, _ := s.GetGroup("ops")
+	delete(group.Keys, "bob")
+	s.SetGroup(group)
+	s.DeleteGroup("dev")
+	if count("alice") != 1 || count("bob") != 0 {
+		log.Print("index wasn't updated")
+		t.Fail()
+	}
+}
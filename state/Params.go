@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const paramsKey = "params"
+
+// MaxProofOfWorkCost is the highest proof of work cost the params may require
+const MaxProofOfWorkCost = 32
+
+// DefaultBudgetBlocks is the length of a budget window if the params don't set one
+const DefaultBudgetBlocks = 100
+
+// Params are the parameters of the chain, they are changed by governance transactions of the admins
+type Params struct {
+	// Version is incremented with every change, so approvals of a change can't be replayed
+	Version uint64 `json:"version" mapstructure:"version"`
+	// ProofOfWorkCost is the number of zero bits the proof of work of every transaction needs
+	ProofOfWorkCost byte `json:"proofOfWorkCost" mapstructure:"proofOfWorkCost"`
+	// Admins are the accounts which may change the params
+	Admins []string `json:"admins,omitempty" mapstructure:"admins"`
+	// AdminThreshold is the number of admins which must approve a change, 0 means 1
+	AdminThreshold int `json:"adminThreshold,omitempty" mapstructure:"adminThreshold"`
+	// TxBudget is the number of transactions an account can send per budget window at the
+	// proof of work cost, every further transaction costs one bit more. 0 disables the budgets.
+	TxBudget int `json:"txBudget,omitempty" mapstructure:"txBudget"`
+	// BudgetBlocks is the length of a budget window in blocks, 0 means DefaultBudgetBlocks
+	BudgetBlocks uint64 `json:"budgetBlocks,omitempty" mapstructure:"budgetBlocks"`
+	// ReputationPerBit is the reputation which lowers the cost of an account by one bit, up to
+	// MaxReputationDiscount bits. 0 disables the discount.
+	ReputationPerBit int `json:"reputationPerBit,omitempty" mapstructure:"reputationPerBit"`
+	// MinProofOfWorkCost is the lowest cost the reputation discount can reach
+	MinProofOfWorkCost byte `json:"minProofOfWorkCost,omitempty" mapstructure:"minProofOfWorkCost"`
+}
+
+// DefaultParams returns the params of chains without genesis params. The proof of work cost is the
+// one clients used before it was configurable. There are no admins, so the params can't be changed.
+func DefaultParams() *Params {
+	return &Params{ProofOfWorkCost: 16}
+}
+
+// Validate checks the consistency of the params
+func (params *Params) Validate() error {
+	if params.ProofOfWorkCost > MaxProofOfWorkCost {
+		return errors.New("proof of work cost is too high")
+	}
+	if params.MinProofOfWorkCost > params.ProofOfWorkCost {
+		return errors.New("minimal proof of work cost is higher than the proof of work cost")
+	}
+	if params.TxBudget < 0 || params.ReputationPerBit < 0 {
+		return errors.New("transaction budget and reputation per bit must not be negative")
+	}
+	if params.AdminThreshold < 0 || params.AdminThreshold > len(params.Admins) {
+		return errors.New("admin threshold must be between 0 and the number of admins")
+	}
+	seen := make(map[string]bool)
+	for _, admin := range params.Admins {
+		if seen[admin] {
+			return errors.New("admin " + admin + " is listed twice")
+		}
+		seen[admin] = true
+	}
+	return nil
+}
+
+// IsAdmin returns true if the account may change the params
+func (params *Params) IsAdmin(id string) bool {
+	for _, admin := range params.Admins {
+		if admin == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAdminThreshold returns the number of admins which must approve a change
+func (params *Params) GetAdminThreshold() int {
+	if params.AdminThreshold < 1 {
+		return 1
+	}
+	return params.AdminThreshold
+}
+
+// GetParams returns the params of the chain, the genesis params if none are stored yet
+func (s *State) GetParams() (*Params, error) {
+	_, bs, exists := s.Tree.Get([]byte(paramsKey))
+	if !exists {
+		if s.Genesis != nil && s.Genesis.Params != nil {
+			params := *s.Genesis.Params
+			return &params, nil
+		}
+		return DefaultParams(), nil
+	}
+	params := &Params{}
+	return params, json.Unmarshal(bs, params)
+}
+
+// SetParams stores the params of the chain
+func (s *State) SetParams(params *Params) error {
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	s.Tree.Set([]byte(paramsKey), bs)
+	return nil
+}
+
+// ProofOfWorkCost returns the proof of work cost transactions currently need
+func (s *State) ProofOfWorkCost() byte {
+	params, err := s.GetParams()
+	if err != nil {
+		return DefaultParams().ProofOfWorkCost
+	}
+	return params.ProofOfWorkCost
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"log"
+	"testing"
+
+	"github.com/tendermint/merkleeyes/iavl"
+)
+
+func TestParams(t *testing.T) {
+	s := NewStateFromTree(iavl.NewIAVLTree(0, nil))
+	if cost := s.ProofOfWorkCost(); cost != DefaultParams().ProofOfWorkCost {
+		log.Printf("wrong default cost: %v", cost)
+		t.Fail()
+	}
+	s.Genesis = &Genesis{
+		Params:   &Params{ProofOfWorkCost: 8, Admins: []string{"alice"}},
+		Accounts: []*Account{{ID: "alice", PubKey: "key"}},
+	}
+	if err := s.EnsureGenesis(); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if !s.HasAccount("alice") {
+		log.Print("genesis account wasn't created")
+		t.Fail()
+	}
+	// the stored params win over the genesis params from now on
+	s.Genesis = &Genesis{Params: &Params{ProofOfWorkCost: 4}}
+	params, err := s.GetParams()
+	if err != nil || params.ProofOfWorkCost != 8 || !params.IsAdmin("alice") {
+		log.Printf("wrong params: %+v, %v", params, err)
+		t.Fail()
+	}
+	for _, invalid := range []*Params{
+		{ProofOfWorkCost: MaxProofOfWorkCost + 1},
+		{Admins: []string{"alice"}, AdminThreshold: 2},
+		{Admins: []string{"alice", "alice"}},
+	} {
+		if invalid.Validate() == nil {
+			log.Printf("invalid params passed: %+v", invalid)
+			t.Fail()
+		}
+	}
+}
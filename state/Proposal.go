@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProposalLifetime is the number of blocks after which a proposal expires
+const ProposalLifetime uint64 = 1000
+
+// ProposalAction is the operation a proposal executes once it is approved
+type ProposalAction string
+
+const (
+	// ProposalDelete deletes the secret
+	ProposalDelete ProposalAction = "delete"
+	// ProposalUnshare removes the share of an account
+	ProposalUnshare ProposalAction = "unshare"
+	// ProposalUpdate replaces the secret, e.g. to change its admins
+	ProposalUpdate ProposalAction = "update"
+)
+
+// Proposal is a pending operation on a secret which needs the approval of several admins
+type Proposal struct {
+	ID       string         `json:"id" mapstructure:"id"`
+	SecretID string         `json:"secret" mapstructure:"secret"`
+	Proposer string         `json:"proposer" mapstructure:"proposer"`
+	Action   ProposalAction `json:"action" mapstructure:"action"`
+	// AccountID is the account to remove for unshare proposals
+	AccountID string `json:"account,omitempty" mapstructure:"account"`
+	// Secret is the new secret for update proposals and the rekeyed secret for unshare proposals
+	Secret *Secret `json:"newSecret,omitempty" mapstructure:"newSecret"`
+	// Version is the version of the secret the proposal is based on, it can't be executed after other changes
+	Version uint64 `json:"version" mapstructure:"version"`
+	// Approvals holds the admins which approved the proposal, including the proposer
+	Approvals map[string]bool `json:"approvals" mapstructure:"approvals"`
+	// ExpiresAt is the height at which the proposal is dropped
+	ExpiresAt uint64 `json:"expiresAt" mapstructure:"expiresAt"`
+}
+
+// IsValid returns true if action is a known proposal action
+func (action ProposalAction) IsValid() bool {
+	return action == ProposalDelete || action == ProposalUnshare || action == ProposalUpdate
+}
+
+// ValidateApprovals checks that the admins of a secret can reach its required approvals
+func (secret *Secret) ValidateApprovals() error {
+	if secret.RequiredApprovals < 0 || secret.RequiredApprovals > secret.CountAdmins() {
+		return errors.New("the required approvals must be between 0 and the number of admins")
+	}
+	return nil
+}
+
+// ApprovalsFor returns the number of admins which must approve changing old into secret,
+// which is the stricter one of both policies. secret is nil for deletions.
+func (old *Secret) ApprovalsFor(secret *Secret) int {
+	if secret != nil && secret.RequiredApprovals > old.RequiredApprovals {
+		return secret.RequiredApprovals
+	}
+	return old.RequiredApprovals
+}
+
+// NeedsApproval returns true if changing old into secret needs the approval of several admins.
+// secret is nil for deletions.
+func (old *Secret) NeedsApproval(secret *Secret) bool {
+	if old.ApprovalsFor(secret) < 2 {
+		return false
+	}
+	if secret == nil || secret.RequiredApprovals != old.RequiredApprovals {
+		return true
+	}
+	for account := range old.Shares {
+		if (old.RoleOf(account) == RoleAdmin) != (secret.RoleOf(account) == RoleAdmin) {
+			return true
+		}
+	}
+	for account := range secret.Shares {
+		if (old.RoleOf(account) == RoleAdmin) != (secret.RoleOf(account) == RoleAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
+func proposalKey(secret, id string) []byte {
+	return []byte(proposalPrefix + secret + "::" + id)
+}
+
+// AddProposal stores a new proposal which expires after ProposalLifetime blocks
+func (s *State) AddProposal(proposal *Proposal) error {
+	if s.Tree.Has(proposalKey(proposal.SecretID, proposal.ID)) {
+		return errors.New("proposal already exists")
+	}
+	proposal.ExpiresAt = s.Height + ProposalLifetime
+	return s.SetProposal(proposal)
+}
+
+func (s *State) SetProposal(proposal *Proposal) error {
+	bs, err := json.Marshal(proposal)
+	if err != nil {
+		return err
+	}
+	s.Tree.Set(proposalKey(proposal.SecretID, proposal.ID), bs)
+	return nil
+}
+
+func (s *State) GetProposal(secret, id string) (*Proposal, error) {
+	_, bs, exists := s.Tree.Get(proposalKey(secret, id))
+	if !exists {
+		return nil, errors.New("no such proposal")
+	}
+	proposal := &Proposal{Approvals: make(map[string]bool)}
+	return proposal, json.Unmarshal(bs, proposal)
+}
+
+func (s *State) DeleteProposal(secret, id string) error {
+	_, removed := s.Tree.Remove(proposalKey(secret, id))
+	if !removed {
+		return errors.New("no such proposal")
+	}
+	return nil
+}
+
+// GetProposals returns the proposals of a secret, or of all secrets if secret is empty
+func (s *State) GetProposals(secret string) (result []*Proposal, err error) {
+	result = make([]*Proposal, 0)
+	start := proposalPrefix
+	if secret != "" {
+		start = string(proposalKey(secret, ""))
+	}
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		proposal := &Proposal{}
+		if err = json.Unmarshal(value, proposal); err != nil {
+			return true
+		}
+		// skip the proposals of secrets whose id starts with secret + "::"
+		if secret == "" || proposal.SecretID == secret {
+			result = append(result, proposal)
+		}
+		return false
+	})
+	return
+}
+
+// DeleteProposals removes all proposals of a secret
+func (s *State) DeleteProposals(secret string) error {
+	proposals, err := s.GetProposals(secret)
+	if err != nil {
+		return err
+	}
+	for _, proposal := range proposals {
+		s.Tree.Remove(proposalKey(proposal.SecretID, proposal.ID))
+	}
+	return nil
+}
+
+// ExpireProposals removes all proposals which expired at the current height.
+// It must be called at the same point of the chain on every node, e.g. in BeginBlock.
+func (s *State) ExpireProposals() error {
+	proposals, err := s.GetProposals("")
+	if err != nil {
+		return err
+	}
+	for _, proposal := range proposals {
+		if proposal.ExpiresAt <= s.Height {
+			s.Tree.Remove(proposalKey(proposal.SecretID, proposal.ID))
+		}
+	}
+	return nil
+}
+
+// NewProposalID returns the id of a proposal made by proposer with the given transaction sequence
+func NewProposalID(proposer string, sequence uint64) string {
+	return fmt.Sprintf("%v-%v", proposer, sequence)
+}
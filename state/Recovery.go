@@ -0,0 +1,146 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"errors"
+)
+
+// DefaultRecoveryDelay is the recovery delay of accounts which didn't configure one
+const DefaultRecoveryDelay uint64 = 3 * 24 * 60 * 60
+
+// AccountRecovery is a recovery of an account by its guardians, which installs a new key
+type AccountRecovery struct {
+	// PubKey is the key which will be installed, it is set once enough guardians approved it
+	PubKey string `json:"pubkey,omitempty" mapstructure:"pubkey"`
+	// Approvals holds the key each guardian approved
+	Approvals map[string]string `json:"approvals" mapstructure:"approvals"`
+	// ExecutesAt is the unix time at which the key is installed, 0 until enough guardians approved
+	ExecutesAt uint64 `json:"executesAt,omitempty" mapstructure:"executesAt"`
+}
+
+// GetRecoveryDelay returns the recovery delay of the account in seconds
+func (account *Account) GetRecoveryDelay() uint64 {
+	if account.RecoveryDelay == 0 {
+		return DefaultRecoveryDelay
+	}
+	return account.RecoveryDelay
+}
+
+// CanApproveRecovery returns an error if guardian can't approve the recovery of the account with pubKey
+func (account *Account) CanApproveRecovery(guardian, pubKey string) error {
+	if account.GuardianThreshold == 0 {
+		return errors.New("account has no guardians to recover it")
+	}
+	if !account.IsGuardian(guardian) {
+		return errors.New(guardian + " is not a guardian of this account")
+	}
+	if account.Recovery == nil {
+		return nil
+	}
+	if account.Recovery.ExecutesAt != 0 && account.Recovery.PubKey != pubKey {
+		return errors.New("a recovery with another key is already approved")
+	}
+	if account.Recovery.Approvals[guardian] == pubKey {
+		return errors.New("recovery is already approved by " + guardian)
+	}
+	return nil
+}
+
+// ApproveRecovery records that a guardian approves the recovery of an account with pubKey.
+// Approvals are counted per key, a guardian approving another key only moves its own approval.
+// Once enough guardians approved a key, it is installed after the recovery delay of the account.
+func (s *State) ApproveRecovery(id, guardian, pubKey string) error {
+	acc, err := s.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	if err = acc.CanApproveRecovery(guardian, pubKey); err != nil {
+		return err
+	}
+	if acc.Recovery == nil {
+		acc.Recovery = &AccountRecovery{Approvals: make(map[string]string)}
+	}
+	acc.Recovery.Approvals[guardian] = pubKey
+	if acc.Recovery.ExecutesAt == 0 && acc.Recovery.approvalsOf(pubKey) >= acc.GuardianThreshold {
+		acc.Recovery.PubKey = pubKey
+		acc.Recovery.ExecutesAt = s.Time + acc.GetRecoveryDelay()
+	}
+	s.Tree.Set([]byte(recoveryPrefix+id), []byte{})
+	return s.SetAccount(acc)
+}
+
+// approvalsOf returns the number of guardians which approved pubKey
+func (recovery *AccountRecovery) approvalsOf(pubKey string) int {
+	count := 0
+	for _, approved := range recovery.Approvals {
+		if approved == pubKey {
+			count++
+		}
+	}
+	return count
+}
+
+// CancelRecovery removes the pending recovery of an account
+func (s *State) CancelRecovery(id string) error {
+	acc, err := s.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	if acc.Recovery == nil {
+		return errors.New("no pending recovery")
+	}
+	acc.Recovery = nil
+	s.Tree.Remove([]byte(recoveryPrefix + id))
+	return s.SetAccount(acc)
+}
+
+// ExecuteRecoveries installs the keys of all recoveries whose delay passed.
+// It must be called at the same point of the chain on every node, e.g. in BeginBlock.
+func (s *State) ExecuteRecoveries() error {
+	ids := make([]string, 0)
+	start := recoveryPrefix
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		ids = append(ids, string(key[len(start):]))
+		return false
+	})
+	for _, id := range ids {
+		acc, err := s.GetAccount(id)
+		if err != nil {
+			return err
+		}
+		if acc.Recovery == nil {
+			s.Tree.Remove([]byte(recoveryPrefix + id))
+			continue
+		}
+		if acc.Recovery.ExecutesAt == 0 || acc.Recovery.ExecutesAt > s.Time {
+			continue
+		}
+		acc.PubKey = acc.Recovery.PubKey
+		acc.Recovery = nil
+		s.Tree.Remove([]byte(recoveryPrefix + id))
+		if err = s.SetAccount(acc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
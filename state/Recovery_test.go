@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"log"
+	"testing"
+
+	"github.com/tendermint/merkleeyes/iavl"
+)
+
+func TestRecovery(t *testing.T) {
+	s := NewStateFromTree(iavl.NewIAVLTree(0, nil))
+	s.Time = 1000
+	for _, acc := range []*Account{
+		{ID: "alice", PubKey: "old", Guardians: []string{"bob", "carol"}, GuardianThreshold: 2, RecoveryDelay: 100},
+		{ID: "bob"},
+		{ID: "carol"},
+	} {
+		if err := s.AddAccount(acc); err != nil {
+			log.Print(err)
+			t.Fail()
+		}
+	}
+	if err := s.ApproveRecovery("alice", "alice", "new"); err == nil {
+		log.Print("approved recovery by non guardian")
+		t.Fail()
+	}
+	if err := s.ApproveRecovery("alice", "bob", "new"); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if err := s.ApproveRecovery("alice", "bob", "new"); err == nil {
+		log.Print("approved recovery twice")
+		t.Fail()
+	}
+	if err := s.ApproveRecovery("alice", "carol", "other"); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if acc, _ := s.GetAccount("alice"); acc.Recovery.Approvals["bob"] != "new" || acc.Recovery.ExecutesAt != 0 {
+		log.Print("approval of another key replaced the pending approvals")
+		t.Fail()
+	}
+	if err := s.ApproveRecovery("alice", "carol", "new"); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if err := s.ApproveRecovery("alice", "carol", "other"); err == nil {
+		log.Print("replaced approved recovery")
+		t.Fail()
+	}
+	s.Time = 1099
+	if err := s.ExecuteRecoveries(); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if acc, _ := s.GetAccount("alice"); acc.PubKey != "old" {
+		log.Print("recovery executed before its delay")
+		t.Fail()
+	}
+	s.Time = 1100
+	if err := s.ExecuteRecoveries(); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if acc, _ := s.GetAccount("alice"); acc.PubKey != "new" || acc.Recovery != nil {
+		log.Print("recovery not executed")
+		t.Fail()
+	}
+	if err := s.CancelRecovery("alice"); err == nil {
+		log.Print("canceled executed recovery")
+		t.Fail()
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"fmt"
+)
+
+// Role is the access level of an account on a secret. Every role includes the rights of the lower ones.
+type Role string
+
+const (
+	// RoleReader can read the secret
+	RoleReader Role = "reader"
+	// RoleWriter can additionally update the value and the metadata
+	RoleWriter Role = "writer"
+	// RoleSharer can additionally share the secret with readers
+	RoleSharer Role = "sharer"
+	// RoleAdmin can additionally delete the secret and grant or revoke any role
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleReader: 1,
+	RoleWriter: 2,
+	RoleSharer: 3,
+	RoleAdmin:  4,
+}
+
+// ParseRole returns the role with the given name
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if !role.IsValid() {
+		return "", fmt.Errorf("unknown role %v", name)
+	}
+	return role, nil
+}
+
+func (role Role) IsValid() bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// Includes returns true if role grants all rights of other
+func (role Role) Includes(other Role) bool {
+	return role.IsValid() && other.IsValid() && roleLevels[role] >= roleLevels[other]
+}
+
+// RoleOf returns the role of an account on the secret or an empty role if it has no share.
+// Secrets written before roles existed only know owners, which are admins, and readers.
+func (secret *Secret) RoleOf(account string) Role {
+	if _, ok := secret.Shares[account]; !ok {
+		return ""
+	}
+	if role, ok := secret.Roles[account]; ok {
+		return role
+	}
+	if secret.Owners[account] {
+		return RoleAdmin
+	}
+	return RoleReader
+}
+
+// CountAdmins returns the number of accounts with the admin role
+func (secret *Secret) CountAdmins() int {
+	count := 0
+	for account := range secret.Shares {
+		if secret.RoleOf(account) == RoleAdmin {
+			count++
+		}
+	}
+	return count
+}
+
+// ValidateRoles checks that all roles are known and belong to an account with a share
+func (secret *Secret) ValidateRoles() error {
+	for account, role := range secret.Roles {
+		if !role.IsValid() {
+			return fmt.Errorf("unknown role %v", role)
+		}
+		if _, ok := secret.Shares[account]; !ok {
+			return fmt.Errorf("%v has a role but no share", account)
+		}
+	}
+	return nil
+}
+
+// normalizeRoles replaces the legacy owner flags with roles
+func (secret *Secret) normalizeRoles() {
+	roles := make(map[string]Role)
+	for account := range secret.Shares {
+		roles[account] = secret.RoleOf(account)
+	}
+	secret.Roles = roles
+	secret.Owners = nil
+}
@@ -30,31 +30,117 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
 )
 
+// aeadPrefix marks secret values which are encrypted with AES-256-GCM.
+// Values without a prefix are legacy values encrypted with AES in OFB mode.
+const aeadPrefix = "v2:"
+
 type Secret struct {
 	ID     string            `json:"id" mapstructure:"id"`
 	Value  string            `json:"value" mapstructure:"value"`
 	Shares map[string]string `json:"shares" mapstructure:"shares"`
-	Owners map[string]bool   `json:"owners" mapstructure:"owners"`
+	// Owners is only used by secrets written before Roles existed
+	Owners   map[string]bool `json:"owners,omitempty" yaml:"owners,omitempty" mapstructure:"owners"`
+	Roles    map[string]Role `json:"roles,omitempty" mapstructure:"roles"`
+	Version  uint64          `json:"version" mapstructure:"version"`
+	Metadata *SecretMetadata `json:"metadata,omitempty" yaml:"metadata,omitempty" mapstructure:"metadata"`
+	// ExpiresAt is the unix time at which the secret expires, 0 means never
+	ExpiresAt uint64 `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty" mapstructure:"expiresAt"`
+	// RotateEvery is the number of seconds after which the value should be rotated, 0 means never
+	RotateEvery uint64 `json:"rotateEvery,omitempty" yaml:"rotateEvery,omitempty" mapstructure:"rotateEvery"`
+	// RotatedAt is the block time at which the value was last changed, it is set by the chain
+	RotatedAt uint64 `json:"rotatedAt,omitempty" yaml:"rotatedAt,omitempty" mapstructure:"rotatedAt"`
+	// Threshold is the number of members needed to unlock the secret, 0 means everyone with a share can read it.
+	// If it is set, the shares hold parts of the data key which are split with Shamir's scheme.
+	Threshold int `json:"threshold,omitempty" yaml:"threshold,omitempty" mapstructure:"threshold"`
+	// RequiredApprovals is the number of admins which must approve the deletion of the secret
+	// and changes to its admins, 0 or 1 means a single admin can do it
+	RequiredApprovals int `json:"requiredApprovals,omitempty" yaml:"requiredApprovals,omitempty" mapstructure:"requiredApprovals"`
+}
+
+// SecretVersion is an entry in the history of a secret
+type SecretVersion struct {
+	Version uint64  `json:"version" mapstructure:"version"`
+	Height  uint64  `json:"height" mapstructure:"height"`
+	Author  string  `json:"author" mapstructure:"author"`
+	Secret  *Secret `json:"secret" mapstructure:"secret"`
 }
 
-func (s *State) AddSecret(secret *Secret) error {
+func (s *State) AddSecret(secret *Secret, author string) error {
 	if s.HasSecret(secret.ID) {
 		return errors.New("secret already exists")
 	}
-	return s.SetSecret(secret)
+	secret.Version = 1
+	secret.RotatedAt = s.Time
+	if err := s.SetSecret(secret); err != nil {
+		return err
+	}
+	return s.addSecretVersion(secret, author)
+}
+
+// UpdateSecret stores a new version of a secret while keeping the previous ones in its history
+func (s *State) UpdateSecret(secret *Secret, author string) error {
+	return s.updateSecret(secret, author, true)
+}
+
+// RekeySecret stores a secret which has been encrypted with a new data key.
+// Unlike UpdateSecret it doesn't count as a rotation of the value.
+func (s *State) RekeySecret(secret *Secret, author string) error {
+	if err := s.DeleteUnlockRequests(secret.ID); err != nil {
+		return err
+	}
+	return s.updateSecret(secret, author, false)
+}
+
+func (s *State) updateSecret(secret *Secret, author string, isValueUpdate bool) error {
+	old, err := s.GetSecret(secret.ID)
+	if err != nil {
+		return err
+	}
+	if old.Version == 0 {
+		// the secret was created before versions were recorded, so keep its value as version 0
+		if err = s.addSecretVersion(old, ""); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(old.Shares, secret.Shares) {
+		// the contributions of unlock requests are parts of the old key
+		if err = s.DeleteUnlockRequests(secret.ID); err != nil {
+			return err
+		}
+	}
+	secret.Version = old.Version + 1
+	secret.RotatedAt = old.RotatedAt
+	if isValueUpdate && secret.Value != old.Value {
+		secret.RotatedAt = s.Time
+	}
+	if err = s.SetSecret(secret); err != nil {
+		return err
+	}
+	return s.addSecretVersion(secret, author)
 }
 
 func (s *State) SetSecret(secret *Secret) error {
+	secret.normalizeRoles()
 	bs, err := json.Marshal(secret)
 	if err != nil {
 		return err
 	}
+	var old *Secret
+	if s.HasSecret(secret.ID) {
+		if old, err = s.GetSecret(secret.ID); err != nil {
+			return err
+		}
+	}
 	s.Tree.Set([]byte(secretPrefix+secret.ID), bs)
-	return nil
+	return s.updateSecretIndex(old, secret)
 }
 
 func (s *State) HasSecret(id string) bool {
@@ -66,35 +152,152 @@ func (s *State) GetSecret(id string) (*Secret, error) {
 	if !exists {
 		return nil, errors.New("no such secret")
 	}
-	acc := &Secret{Shares: make(map[string]string)}
-	return acc, json.Unmarshal(bs, acc)
+	secret := &Secret{Shares: make(map[string]string)}
+	if err := json.Unmarshal(bs, secret); err != nil {
+		return nil, err
+	}
+	secret.normalizeRoles()
+	return secret, nil
 }
 
 func (s *State) DeleteSecret(id string) error {
-	_, removed := s.Tree.Remove([]byte(secretPrefix + id))
-	if !removed {
-		return errors.New("no such secret")
+	old, err := s.GetSecret(id)
+	if err != nil {
+		return err
+	}
+	s.Tree.Remove([]byte(secretPrefix + id))
+	if err = s.updateSecretIndex(old, nil); err != nil {
+		return err
+	}
+	keys := make([][]byte, 0)
+	s.iterateSecretHistory(id, func(key []byte, value []byte) bool {
+		keys = append(keys, key)
+		return false
+	})
+	for _, key := range keys {
+		s.Tree.Remove(key)
+	}
+	if err = s.DeleteUnlockRequests(id); err != nil {
+		return err
 	}
+	return s.DeleteProposals(id)
+}
+
+func (s *State) addSecretVersion(secret *Secret, author string) error {
+	bs, err := json.Marshal(&SecretVersion{
+		Version: secret.Version,
+		Height:  s.Height,
+		Author:  author,
+		Secret:  secret,
+	})
+	if err != nil {
+		return err
+	}
+	s.Tree.Set([]byte(secretVersionKey(secret.ID, secret.Version)), bs)
 	return nil
 }
 
-func (s *State) ListSecrets() (result []*Secret, err error) {
-	start := secretPrefix
-	end := start[:len(start)-1]
-	end = end + string(start[len(start)-1]+1)
-	result = make([]*Secret, 0)
-	s.Tree.IterateRange([]byte(start), []byte(end), true, func(key []byte, value []byte) bool {
-		acc := &Secret{}
-		err = json.Unmarshal(value, acc)
+// secretVersionKey pads the version so that the history is iterated in order
+func secretVersionKey(id string, version uint64) string {
+	return fmt.Sprintf("%v%v::%020d", secretHistoryPrefix, id, version)
+}
+
+func (s *State) iterateSecretHistory(id string, fn func(key []byte, value []byte) bool) {
+	start := secretHistoryPrefix + id + "::"
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		// skip the history of secrets whose id starts with id + "::"
+		if len(key) != len(secretVersionKey(id, 0)) {
+			return false
+		}
+		return fn(key, value)
+	})
+}
+
+// GetSecretHistory returns all recorded versions of a secret, oldest first
+func (s *State) GetSecretHistory(id string) (result []*SecretVersion, err error) {
+	result = make([]*SecretVersion, 0)
+	s.iterateSecretHistory(id, func(key []byte, value []byte) bool {
+		version := &SecretVersion{}
+		err = json.Unmarshal(value, version)
 		if err != nil {
 			return true
 		}
-		result = append(result, acc)
+		result = append(result, version)
 		return false
 	})
 	return
 }
 
+func (s *State) GetSecretVersion(id string, version uint64) (*SecretVersion, error) {
+	_, bs, exists := s.Tree.Get([]byte(secretVersionKey(id, version)))
+	if !exists {
+		return nil, errors.New("no such secret version")
+	}
+	result := &SecretVersion{}
+	return result, json.Unmarshal(bs, result)
+}
+
+// SecretPage is a page of a secret list
+type SecretPage struct {
+	Secrets []*Secret `json:"secrets"`
+	// Next is the start of the next page, it is empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// ListSecrets returns a page of the secrets matching opts
+func (s *State) ListSecrets(opts *ListOptions) (*SecretPage, error) {
+	page := &SecretPage{Secrets: make([]*Secret, 0)}
+	next, err := s.iterateList(secretPrefix, opts, func(id string, value []byte) error {
+		secret := &Secret{}
+		if err := json.Unmarshal(value, secret); err != nil {
+			return err
+		}
+		page.Secrets = append(page.Secrets, secret)
+		return nil
+	})
+	page.Next = next
+	return page, err
+}
+
+// ListDueSecrets returns the secrets shared with an account which are expired or due for rotation at the given unix time
+func (s *State) ListDueSecrets(accountID string, now uint64) ([]*Secret, error) {
+	shared, err := s.GetSharedSecrets(accountID)
+	if err != nil {
+		return nil, err
+	}
+	ids := append(shared.Owned, shared.Readable...)
+	sort.Strings(ids)
+	result := make([]*Secret, 0)
+	for _, id := range ids {
+		secret, err := s.GetSecret(id)
+		if err != nil {
+			return nil, err
+		}
+		if secret.IsExpired(now) || secret.IsRotationDue(now) {
+			result = append(result, secret)
+		}
+	}
+	return result, nil
+}
+
+// IsExpired returns true if the secret has an expiry date which is not after now
+func (secret *Secret) IsExpired(now uint64) bool {
+	return secret.ExpiresAt != 0 && secret.ExpiresAt <= now
+}
+
+// RotationDueAt returns the unix time at which the value has to be rotated, 0 if it never has to
+func (secret *Secret) RotationDueAt() uint64 {
+	if secret.RotateEvery == 0 {
+		return 0
+	}
+	return secret.RotatedAt + secret.RotateEvery
+}
+
+// IsRotationDue returns true if the value should have been rotated by now
+func (secret *Secret) IsRotationDue(now uint64) bool {
+	return secret.RotateEvery != 0 && secret.RotationDueAt() <= now
+}
+
 func (secret *Secret) Encrypt() (aesKey []byte, err error) {
 	k := make([]byte, 32)
 	if _, err = io.ReadFull(rand.Reader, k); err != nil {
@@ -104,31 +307,87 @@ func (secret *Secret) Encrypt() (aesKey []byte, err error) {
 	return key[:], secret.EncryptWithKey(key[:])
 }
 
+// EncryptWithKey encrypts the value and the encrypted metadata fields with AES-256-GCM.
+// The secret ID is bound to the ciphertext as associated data, so a value can't be moved to another secret.
 func (secret *Secret) EncryptWithKey(key []byte) error {
-	block, err := aes.NewCipher(key[:])
+	aead, err := newAEAD(key)
 	if err != nil {
 		return err
 	}
-	iv := make([]byte, aes.BlockSize)
-	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+	if secret.Value, err = seal(aead, secret.Value, secret.ID); err != nil {
 		return err
 	}
-	buf := &bytes.Buffer{}
-	_, err = buf.Write(iv)
+	if secret.Metadata == nil {
+		return nil
+	}
+	return secret.Metadata.transformEncrypted(func(name, value string) (string, error) {
+		return seal(aead, value, secret.ID+"::"+name)
+	})
+}
+
+// IsLegacy returns true if the encrypted value uses the old unauthenticated format
+func (secret *Secret) IsLegacy() bool {
+	return !strings.HasPrefix(secret.Value, aeadPrefix)
+}
+
+func (secret *Secret) Decrypt(key []byte) error {
+	aead, err := newAEAD(key)
 	if err != nil {
 		return err
 	}
-	stream := cipher.NewOFB(block, iv[:])
-	writer := &cipher.StreamWriter{S: stream, W: buf}
-	_, err = writer.Write([]byte(secret.Value))
-	if err != nil {
+	if secret.IsLegacy() {
+		err = secret.decryptLegacy(key)
+	} else {
+		secret.Value, err = open(aead, secret.Value, secret.ID)
+	}
+	if err != nil || secret.Metadata == nil {
 		return err
 	}
-	secret.Value = base64.StdEncoding.EncodeToString(buf.Bytes())
-	return nil
+	return secret.Metadata.transformEncrypted(func(name, value string) (string, error) {
+		return open(aead, value, secret.ID+"::"+name)
+	})
 }
 
-func (secret *Secret) Decrypt(key []byte) error {
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plainText and returns it in the prefixed base64 format
+func seal(aead cipher.AEAD, plainText, additionalData string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	cipherText := aead.Seal(nonce, nonce, []byte(plainText), []byte(additionalData))
+	return aeadPrefix + base64.StdEncoding.EncodeToString(cipherText), nil
+}
+
+// open decrypts a value produced by seal
+func open(aead cipher.AEAD, value, additionalData string) (string, error) {
+	if !strings.HasPrefix(value, aeadPrefix) {
+		return "", errors.New("unknown ciphertext format")
+	}
+	cipherText, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, aeadPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(cipherText) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce := cipherText[:aead.NonceSize()]
+	data, err := aead.Open(nil, nonce, cipherText[aead.NonceSize():], []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decryptLegacy decrypts values which were encrypted with AES in OFB mode
+func (secret *Secret) decryptLegacy(key []byte) error {
 	valueBytes, err := base64.StdEncoding.DecodeString(secret.Value)
 	if err != nil {
 		return err
@@ -137,7 +396,7 @@ func (secret *Secret) Decrypt(key []byte) error {
 	iv := make([]byte, aes.BlockSize)
 	bs, err := buf.Read(iv[:])
 	if bs != aes.BlockSize {
-		return errors.New("ciphertext to short")
+		return errors.New("ciphertext too short")
 	}
 	if err != nil {
 		return err
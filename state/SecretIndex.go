@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// SecretRef is an entry of the index of secrets which are shared with an account
+type SecretRef struct {
+	Account string `json:"account"`
+	Secret  string `json:"secret"`
+	Role    Role   `json:"role"`
+}
+
+// SharedSecrets lists the ids of the secrets which are shared with an account,
+// split into those it is admin of and those with a lower role
+type SharedSecrets struct {
+	Owned    []string `json:"owned"`
+	Readable []string `json:"readable"`
+}
+
+func secretIndexKey(account, secret string) []byte {
+	return []byte(secretIndexPrefix + account + "::" + secret)
+}
+
+// updateSecretIndex updates the index entries of a secret which changed from old to secret.
+// old is nil for new secrets and secret is nil for deleted ones.
+func (s *State) updateSecretIndex(old, secret *Secret) error {
+	// the tree must be changed in the same order on every node
+	if old != nil {
+		for _, account := range sortedAccounts(old.Shares) {
+			if secret == nil {
+				s.Tree.Remove(secretIndexKey(account, old.ID))
+			} else if _, ok := secret.Shares[account]; !ok {
+				s.Tree.Remove(secretIndexKey(account, old.ID))
+			}
+		}
+	}
+	if secret == nil {
+		return nil
+	}
+	for _, account := range sortedAccounts(secret.Shares) {
+		bs, err := json.Marshal(&SecretRef{account, secret.ID, secret.RoleOf(account)})
+		if err != nil {
+			return err
+		}
+		s.Tree.Set(secretIndexKey(account, secret.ID), bs)
+	}
+	return nil
+}
+
+func sortedAccounts(shares map[string]string) []string {
+	accounts := make([]string, 0, len(shares))
+	for account := range shares {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// GetSharedSecrets returns the ids of the secrets an account has a share of
+func (s *State) GetSharedSecrets(account string) (result *SharedSecrets, err error) {
+	result = &SharedSecrets{Owned: make([]string, 0), Readable: make([]string, 0)}
+	start := string(secretIndexKey(account, ""))
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		ref := &SecretRef{}
+		if err = json.Unmarshal(value, ref); err != nil {
+			return true
+		}
+		// skip the entries of accounts whose id starts with account + "::"
+		if ref.Account != account {
+			return false
+		}
+		if ref.Role == RoleAdmin {
+			result.Owned = append(result.Owned, ref.Secret)
+		} else {
+			result.Readable = append(result.Readable, ref.Secret)
+		}
+		return false
+	})
+	return
+}
+
+// EnsureSecretIndex builds the index of shared secrets if the state was created before it existed.
+// It must be called at the same point of the chain on every node, e.g. in BeginBlock.
+func (s *State) EnsureSecretIndex() error {
+	if s.Tree.Has([]byte(secretIndexMarker)) {
+		return nil
+	}
+	page, err := s.ListSecrets(&ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, secret := range page.Secrets {
+		if err = s.updateSecretIndex(nil, secret); err != nil {
+			return err
+		}
+	}
+	s.Tree.Set([]byte(secretIndexMarker), []byte("1"))
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldPrefix is used to reference custom fields in SecretMetadata.Encrypted
+const fieldPrefix = "fields."
+
+// SecretMetadata holds descriptive data of a secret. It is stored in plaintext,
+// except for the fields listed in Encrypted which are encrypted with the data key of the secret.
+// Custom fields are referenced as "fields.<key>".
+type SecretMetadata struct {
+	Description string            `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description"`
+	Username    string            `json:"username,omitempty" yaml:"username,omitempty" mapstructure:"username"`
+	URL         string            `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url"`
+	Notes       string            `json:"notes,omitempty" yaml:"notes,omitempty" mapstructure:"notes"`
+	Tags        []string          `json:"tags,omitempty" yaml:"tags,omitempty" mapstructure:"tags"`
+	Fields      map[string]string `json:"fields,omitempty" yaml:"fields,omitempty" mapstructure:"fields"`
+	Encrypted   []string          `json:"encrypted,omitempty" yaml:"encrypted,omitempty" mapstructure:"encrypted"`
+}
+
+// transformEncrypted replaces the value of every field listed in Encrypted with the result of fn
+func (meta *SecretMetadata) transformEncrypted(fn func(name, value string) (string, error)) error {
+	for _, name := range meta.Encrypted {
+		var field *string
+		switch name {
+		case "description":
+			field = &meta.Description
+		case "username":
+			field = &meta.Username
+		case "url":
+			field = &meta.URL
+		case "notes":
+			field = &meta.Notes
+		default:
+			key := strings.TrimPrefix(name, fieldPrefix)
+			value, ok := meta.Fields[key]
+			if !strings.HasPrefix(name, fieldPrefix) || !ok {
+				return fmt.Errorf("unknown metadata field %v", name)
+			}
+			res, err := fn(name, value)
+			if err != nil {
+				return err
+			}
+			meta.Fields[key] = res
+			continue
+		}
+		res, err := fn(name, *field)
+		if err != nil {
+			return err
+		}
+		*field = res
+	}
+	return nil
+}
@@ -22,6 +22,9 @@
 package state
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
 	"log"
 	"testing"
 )
@@ -43,3 +46,137 @@ func TestSecret(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSecretWrongKey(t *testing.T) {
+	s := &Secret{ID: "id", Value: "abc"}
+	if _, err := s.Encrypt(); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	otherKey := make([]byte, 32)
+	if err := s.Decrypt(otherKey); err == nil {
+		log.Print("decrypted with wrong key")
+		t.Fail()
+	}
+}
+
+func TestSecretTampering(t *testing.T) {
+	s := &Secret{ID: "id", Value: "abc"}
+	key, err := s.Encrypt()
+	if err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	cipherText, _ := base64.StdEncoding.DecodeString(s.Value[len(aeadPrefix):])
+	cipherText[len(cipherText)-1] ^= 1
+	tampered := &Secret{ID: "id", Value: aeadPrefix + base64.StdEncoding.EncodeToString(cipherText)}
+	if err = tampered.Decrypt(key); err == nil {
+		log.Print("decrypted tampered ciphertext")
+		t.Fail()
+	}
+	moved := &Secret{ID: "other-id", Value: s.Value}
+	if err = moved.Decrypt(key); err == nil {
+		log.Print("decrypted ciphertext of another secret")
+		t.Fail()
+	}
+}
+
+func TestLegacySecret(t *testing.T) {
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	block, _ := aes.NewCipher(key)
+	cipherText := make([]byte, 3)
+	cipher.NewOFB(block, iv).XORKeyStream(cipherText, []byte("abc"))
+	s := &Secret{ID: "id", Value: base64.StdEncoding.EncodeToString(append(iv, cipherText...))}
+	if !s.IsLegacy() {
+		log.Print("legacy secret not detected")
+		t.Fail()
+	}
+	if err := s.Decrypt(key); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if s.Value != "abc" {
+		log.Print("failed to decrypt legacy secret")
+		t.Fail()
+	}
+	if err := s.EncryptWithKey(key); err != nil || s.IsLegacy() {
+		log.Print("failed to migrate legacy secret")
+		t.Fail()
+	}
+}
+
+func TestSecretMetadata(t *testing.T) {
+	s := &Secret{ID: "id", Value: "abc", Metadata: &SecretMetadata{
+		URL:       "https://example.com",
+		Username:  "alice",
+		Fields:    map[string]string{"pin": "1234", "hint": "birthday"},
+		Encrypted: []string{"username", "fields.pin"},
+	}}
+	key, err := s.Encrypt()
+	if err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if s.Metadata.URL != "https://example.com" || s.Metadata.Fields["hint"] != "birthday" {
+		log.Print("plaintext metadata has been encrypted")
+		t.Fail()
+	}
+	if s.Metadata.Username == "alice" || s.Metadata.Fields["pin"] == "1234" {
+		log.Print("metadata has not been encrypted")
+		t.Fail()
+	}
+	if err = s.Decrypt(key); err != nil {
+		log.Print(err)
+		t.Fail()
+	}
+	if s.Metadata.Username != "alice" || s.Metadata.Fields["pin"] != "1234" {
+		log.Print("failed to decrypt metadata")
+		t.Fail()
+	}
+	s.Metadata.Encrypted = []string{"fields.unknown"}
+	if err = s.EncryptWithKey(key); err == nil {
+		log.Print("encrypted unknown metadata field")
+		t.Fail()
+	}
+}
+
+func TestSecretDue(t *testing.T) {
+	s := &Secret{ExpiresAt: 100, RotateEvery: 10, RotatedAt: 50}
+	if s.IsExpired(99) || !s.IsExpired(100) {
+		log.Print("wrong expiry")
+		t.Fail()
+	}
+	if s.IsRotationDue(59) || !s.IsRotationDue(60) {
+		log.Print("wrong rotation due date")
+		t.Fail()
+	}
+	never := &Secret{}
+	if never.IsExpired(1000) || never.IsRotationDue(1000) {
+		log.Print("secret without expiry and rotation is due")
+		t.Fail()
+	}
+}
+
+func TestSecretRoles(t *testing.T) {
+	s := &Secret{
+		Shares: map[string]string{"alice": "key", "bob": "key", "carol": "key"},
+		Owners: map[string]bool{"alice": true},
+		Roles:  map[string]Role{"carol": RoleWriter},
+	}
+	for account, expected := range map[string]Role{"alice": RoleAdmin, "bob": RoleReader, "carol": RoleWriter, "dave": ""} {
+		if role := s.RoleOf(account); role != expected {
+			log.Printf("expected %v to be %v, got %v", account, expected, role)
+			t.Fail()
+		}
+	}
+	s.normalizeRoles()
+	if s.Owners != nil || s.Roles["alice"] != RoleAdmin || s.Roles["bob"] != RoleReader {
+		log.Print("failed to normalize legacy owners")
+		t.Fail()
+	}
+	if !RoleAdmin.Includes(RoleWriter) || RoleWriter.Includes(RoleSharer) || Role("root").Includes(RoleReader) {
+		log.Print("wrong role hierarchy")
+		t.Fail()
+	}
+}
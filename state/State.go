@@ -22,18 +22,80 @@
 package state
 
 import (
+	"strings"
+
 	"github.com/tendermint/tmlibs/merkle"
 )
 
 const (
-	accountPrefix = "account::"
-	secretPrefix  = "secret::"
+	accountPrefix       = "account::"
+	secretPrefix        = "secret::"
+	secretHistoryPrefix = "secret-history::"
+	secretIndexPrefix   = "account-secret::"
+	groupPrefix         = "group::"
+	groupIndexPrefix    = "account-group::"
+	recoveryPrefix      = "account-recovery::"
+	deletedPrefix       = "account-deleted::"
+	unlockPrefix        = "secret-unlock::"
+	proposalPrefix      = "secret-proposal::"
+	secretIndexMarker   = "account-secret-index"
 )
 
 type State struct {
 	Tree merkle.Tree
+	// Height is the height of the block which is currently processed
+	Height uint64
+	// Time is the unix time of the block which is currently processed
+	Time uint64
+	// Genesis is stored by EnsureGenesis, its params are used until then. nil means DefaultParams.
+	Genesis *Genesis
+}
+
+// ListOptions are the parameters of the list queries
+type ListOptions struct {
+	// Prefix restricts the result to entries whose id starts with it
+	Prefix string `json:"prefix,omitempty"`
+	// Start is the id of the first entry to return, usually the Next value of the previous page
+	Start string `json:"start,omitempty"`
+	// Limit is the maximum number of entries to return, 0 means no limit
+	Limit int `json:"limit,omitempty"`
+}
+
+// iterateList calls fn for every entry below keyPrefix which matches opts, in order of their ids.
+// It returns the id of the first entry which didn't fit into opts.Limit, if any.
+func (s *State) iterateList(keyPrefix string, opts *ListOptions, fn func(id string, value []byte) error) (next string, err error) {
+	start := keyPrefix + opts.Prefix
+	end := prefixEnd(start)
+	if opts.Start > opts.Prefix {
+		start = keyPrefix + opts.Start
+	}
+	count := 0
+	s.Tree.IterateRange([]byte(start), []byte(end), true, func(key []byte, value []byte) bool {
+		id := strings.TrimPrefix(string(key), keyPrefix)
+		if opts.Limit > 0 && count == opts.Limit {
+			next = id
+			return true
+		}
+		count++
+		err = fn(id, value)
+		return err != nil
+	})
+	return
 }
 
 func NewStateFromTree(tree merkle.Tree) *State {
-	return &State{tree}
+	return &State{Tree: tree}
+}
+
+// prefixEnd returns the first key which doesn't start with prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// all keys of this store start with a printable prefix, so this is never reached
+	return prefix
 }
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// UnlockRequest collects the key shares of a threshold secret for one of its members
+type UnlockRequest struct {
+	Secret    string `json:"secret" mapstructure:"secret"`
+	Requester string `json:"requester" mapstructure:"requester"`
+	// Contributions holds the key shares of the other members encrypted for the requester
+	Contributions map[string]string `json:"contributions" mapstructure:"contributions"`
+}
+
+// ValidateThreshold checks that the threshold of a secret can be reached by its members
+func (secret *Secret) ValidateThreshold() error {
+	if secret.Threshold == 0 {
+		return nil
+	}
+	if secret.Threshold < 2 || secret.Threshold > len(secret.Shares) {
+		return errors.New("the threshold must be between 2 and the number of shares")
+	}
+	return nil
+}
+
+func unlockRequestKey(secret, requester string) []byte {
+	return []byte(unlockPrefix + secret + "::" + requester)
+}
+
+// OpenUnlockRequest stores a new unlock request without contributions, replacing an older one of the same requester
+func (s *State) OpenUnlockRequest(secret, requester string) error {
+	return s.SetUnlockRequest(&UnlockRequest{Secret: secret, Requester: requester, Contributions: make(map[string]string)})
+}
+
+// SetUnlockRequest stores an unlock request
+func (s *State) SetUnlockRequest(request *UnlockRequest) error {
+	bs, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	s.Tree.Set(unlockRequestKey(request.Secret, request.Requester), bs)
+	return nil
+}
+
+func (s *State) GetUnlockRequest(secret, requester string) (*UnlockRequest, error) {
+	_, bs, exists := s.Tree.Get(unlockRequestKey(secret, requester))
+	if !exists {
+		return nil, errors.New("no such unlock request")
+	}
+	request := &UnlockRequest{Contributions: make(map[string]string)}
+	return request, json.Unmarshal(bs, request)
+}
+
+// DeleteUnlockRequest removes the unlock request of a requester
+func (s *State) DeleteUnlockRequest(secret, requester string) error {
+	if _, removed := s.Tree.Remove(unlockRequestKey(secret, requester)); !removed {
+		return errors.New("no such unlock request")
+	}
+	return nil
+}
+
+// GetUnlockRequests returns all unlock requests of a secret
+func (s *State) GetUnlockRequests(secret string) (result []*UnlockRequest, err error) {
+	result = make([]*UnlockRequest, 0)
+	start := string(unlockRequestKey(secret, ""))
+	s.Tree.IterateRange([]byte(start), []byte(prefixEnd(start)), true, func(key []byte, value []byte) bool {
+		request := &UnlockRequest{}
+		if err = json.Unmarshal(value, request); err != nil {
+			return true
+		}
+		// skip the requests of secrets whose id starts with secret + "::"
+		if request.Secret == secret {
+			result = append(result, request)
+		}
+		return false
+	})
+	return
+}
+
+// DeleteUnlockRequests removes all unlock requests of a secret.
+// This is needed whenever its key changes, because the contributions are parts of the old key.
+func (s *State) DeleteUnlockRequests(secret string) error {
+	requests, err := s.GetUnlockRequests(secret)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		s.Tree.Remove(unlockRequestKey(request.Secret, request.Requester))
+	}
+	return nil
+}
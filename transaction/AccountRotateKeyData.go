@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transaction
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+type AccountRotateKeyData struct {
+	ID string
+	// PubKey is the new public key of the account
+	PubKey string
+	// Proof is a signature of the approval hash made with the new key
+	Proof string
+	// Approvals maps guardian ids to their signatures of the approval hash
+	Approvals map[string]string `json:",omitempty"`
+	// Shares maps the ids of the secrets shared with the account to their data keys encrypted with the new key
+	Shares map[string]string `json:",omitempty"`
+	// GroupKeys maps the ids of the groups the account is member of to their keys encrypted with the new key
+	GroupKeys map[string]string `json:",omitempty"`
+	// MemberKeys holds the new private key encrypted for each member if the account is a group
+	MemberKeys map[string]string `json:",omitempty"`
+}
+
+// ApprovalHash returns the hash which is signed by the new key and the guardians.
+// It covers the sequence number of the rotation, so approvals can't be replayed.
+func (data *AccountRotateKeyData) ApprovalHash(sequence uint64) []byte {
+	bs, _ := canonicalJSON(map[string]interface{}{
+		"type":     AccountRotateKey,
+		"id":       data.ID,
+		"pubkey":   data.PubKey,
+		"sequence": sequence,
+	})
+	hash := sha3.Sum512(bs)
+	return hash[:]
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transaction
+
+import (
+	"github.com/trusch/passchain/state"
+)
+
+type GroupCreateData struct {
+	Group *state.Group
+	// PubKey is the public key of the account which is created for the group
+	PubKey   string
+	SenderID string
+	// Proof is a signature of the proof hash made with the group key
+	Proof string
+}
+
+// ProofHash returns the hash which is signed with the group key to prove that the sender owns it.
+// It is the hash of tx without the proof, so the proof is bound to this transaction.
+func (data *GroupCreateData) ProofHash(tx *Transaction) ([]byte, error) {
+	unproven := *data
+	unproven.Proof = ""
+	withoutProof := *tx
+	withoutProof.Data = &unproven
+	return withoutProof.Hash()
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transaction
+
+// GroupMemberRemoveData removes a member and rotates the key pair of the group,
+// so the removed member can neither sign as the group nor read what is shared with it later
+type GroupMemberRemoveData struct {
+	ID        string
+	SenderID  string
+	AccountID string
+	// PubKey is the new public key of the group
+	PubKey string
+	// Proof is a signature of the proof hash made with the new key
+	Proof string
+	// Shares maps the ids of the secrets shared with the group to their data keys encrypted with the new key
+	Shares map[string]string `json:",omitempty"`
+	// GroupKeys maps the ids of the groups the group is member of to their keys encrypted with the new key
+	GroupKeys map[string]string `json:",omitempty"`
+	// MemberKeys holds the new private key encrypted for each remaining member
+	MemberKeys map[string]string `json:",omitempty"`
+}
+
+// ProofHash returns the hash which is signed with the new key to prove that the sender owns it.
+// It is the hash of tx without the proof, so the proof is bound to this transaction.
+func (data *GroupMemberRemoveData) ProofHash(tx *Transaction) ([]byte, error) {
+	unproven := *data
+	unproven.Proof = ""
+	withoutProof := *tx
+	withoutProof.Data = &unproven
+	return withoutProof.Hash()
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transaction
+
+import (
+	"github.com/trusch/passchain/state"
+	"golang.org/x/crypto/sha3"
+)
+
+type ParamsSetData struct {
+	SenderID string
+	// Params are the new params, their version must be the current version plus one
+	Params *state.Params
+	// Approvals maps the ids of other admins to their signatures of the approval hash
+	Approvals map[string]string `json:",omitempty"`
+}
+
+// ApprovalHash returns the hash which is signed by the admins which approve the new params.
+// It covers the version of the params, so approvals can't be replayed.
+func (data *ParamsSetData) ApprovalHash() []byte {
+	bs, _ := canonicalJSON(map[string]interface{}{
+		"type":   ParamsSet,
+		"params": data.Params,
+	})
+	hash := sha3.Sum512(bs)
+	return hash[:]
+}
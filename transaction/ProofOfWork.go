@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2017 Tino Rusch
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrNoProofOfWork is returned when no nonce satisfies the proof of work cost
+var ErrNoProofOfWork = errors.New("can not find pow")
+
+// checkInterval is the number of nonces a worker tries between two looks at its context
+const checkInterval = 1024
+
+// ProofOfWorkStats describes the work which was done to solve a proof of work
+type ProofOfWorkStats struct {
+	Hashes   uint64
+	Duration time.Duration
+	Workers  int
+}
+
+// HashRate returns the number of hashes per second
+func (stats *ProofOfWorkStats) HashRate() float64 {
+	if stats.Duration <= 0 {
+		return 0
+	}
+	return float64(stats.Hashes) / stats.Duration.Seconds()
+}
+
+// proofOfWork checks nonces against the hash of a transaction, it reuses its buffers
+type proofOfWork struct {
+	txHash []byte
+	hasher hash.Hash
+	nonce  [4]byte
+	sum    []byte
+}
+
+func newProofOfWork(txHash []byte) *proofOfWork {
+	return &proofOfWork{txHash: txHash, hasher: sha3.New512(), sum: make([]byte, 0, 64)}
+}
+
+// holds returns true if the lowest cost bits of the hash of the transaction hash and nonce are zero
+func (pow *proofOfWork) holds(nonce uint32, cost byte) bool {
+	pow.hasher.Reset()
+	pow.hasher.Write(pow.txHash)
+	binary.LittleEndian.PutUint32(pow.nonce[:], nonce)
+	pow.hasher.Write(pow.nonce[:])
+	pow.sum = pow.hasher.Sum(pow.sum[:0])
+	tip := binary.LittleEndian.Uint64(pow.sum)
+	return tip<<(64-cost) == 0
+}
+
+// ProofOfWork searches a nonce which satisfies the cost and stores it in the transaction.
+// The nonce space is split across GOMAXPROCS workers. The search stops with the error of ctx
+// when ctx is done, the stats are returned in any case.
+func (t *Transaction) ProofOfWork(ctx context.Context, cost byte) (*ProofOfWorkStats, error) {
+	txHash, err := t.Hash()
+	if err != nil {
+		return &ProofOfWorkStats{}, err
+	}
+	workers := runtime.GOMAXPROCS(0)
+	search, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		hashes uint64
+		wg     sync.WaitGroup
+		found  = make(chan uint32, workers)
+		start  = time.Now()
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		// worker i tries the nonces i, i+workers, i+2*workers...
+		go func(first uint64) {
+			defer wg.Done()
+			pow := newProofOfWork(txHash)
+			count := uint64(0)
+			defer func() { atomic.AddUint64(&hashes, count) }()
+			for nonce := first; nonce < 1<<32; nonce += uint64(workers) {
+				if count%checkInterval == 0 && search.Err() != nil {
+					return
+				}
+				count++
+				if pow.holds(uint32(nonce), cost) {
+					found <- uint32(nonce)
+					cancel()
+					return
+				}
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+	stats := &ProofOfWorkStats{Hashes: hashes, Duration: time.Since(start), Workers: workers}
+	select {
+	case nonce := <-found:
+		t.Nonce = nonce
+		return stats, nil
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, ErrNoProofOfWork
+}
+
+// VerifyProofOfWork checks that the nonce of the transaction satisfies the cost
+func (t *Transaction) VerifyProofOfWork(cost byte) error {
+	txHash, err := t.Hash()
+	if err != nil {
+		return err
+	}
+	if newProofOfWork(txHash).holds(t.Nonce, cost) {
+		return nil
+	}
+	return errors.New("failed to validate proof of work")
+}
+
+// ChargedAccount returns the account whose proof of work cost the transaction must pay, which is
+// the account whose sequence it uses. The data may be decoded or read from a file.
+func (t *Transaction) ChargedAccount() (string, error) {
+	bs, err := json.Marshal(t.Data)
+	if err != nil {
+		return "", err
+	}
+	data := struct {
+		SenderID string
+		ID       string
+		From     string
+		Account  *struct {
+			ID string `json:"id"`
+		}
+	}{}
+	if err = json.Unmarshal(bs, &data); err != nil {
+		return "", err
+	}
+	account := data.SenderID
+	switch t.Type {
+	case AccountAdd:
+		if data.Account != nil {
+			account = data.Account.ID
+		}
+	case AccountDel, AccountRotateKey, AccountGuardiansSet, AccountRecoverCancel:
+		account = data.ID
+	case ReputationGive:
+		account = data.From
+	}
+	if account == "" {
+		return "", errors.New("the transaction names no account which pays its proof of work")
+	}
+	return account, nil
+}
@@ -0,0 +1,74 @@
+package transaction_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/trusch/passchain/state"
+	. "github.com/trusch/passchain/transaction"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProofOfWork", func() {
+	It("should find a nonce with all workers and report the work", func() {
+		t := New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}})
+		stats, err := t.ProofOfWork(context.Background(), 12)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.VerifyProofOfWork(12)).To(Succeed())
+		Expect(stats.Workers).To(Equal(runtime.GOMAXPROCS(0)))
+		Expect(stats.Hashes).To(BeNumerically(">", 0))
+		Expect(stats.HashRate()).To(BeNumerically(">", 0))
+	})
+
+	It("should stop when the context is cancelled", func() {
+		t := New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := t.ProofOfWork(ctx, 48)
+		Expect(err).To(Equal(context.Canceled))
+	})
+
+	It("should stop at the deadline of the context", func() {
+		t := New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		stats, err := t.ProofOfWork(ctx, 48)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(stats.Duration).To(BeNumerically("<", time.Second))
+	})
+})
+
+// Run the benchmarks with e.g. -cpu 1,2,4 to see how the solver scales with the number of workers.
+
+func BenchmarkVerifyProofOfWork(b *testing.B) {
+	t := New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}})
+	for i := 0; i < b.N; i++ {
+		t.Nonce = uint32(i)
+		t.VerifyProofOfWork(DefaultProofOfWorkCost)
+	}
+}
+
+func benchmarkProofOfWork(b *testing.B, cost byte) {
+	t := New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}})
+	hashes := uint64(0)
+	elapsed := time.Duration(0)
+	for i := 0; i < b.N; i++ {
+		// every round solves another transaction
+		t.Sequence = uint64(i)
+		stats, err := t.ProofOfWork(context.Background(), cost)
+		if err != nil {
+			b.Fatal(err)
+		}
+		hashes += stats.Hashes
+		elapsed += stats.Duration
+	}
+	b.ReportMetric(float64(hashes)/elapsed.Seconds(), "hashes/s")
+}
+
+func BenchmarkProofOfWork8(b *testing.B)  { benchmarkProofOfWork(b, 8) }
+func BenchmarkProofOfWork12(b *testing.B) { benchmarkProofOfWork(b, 12) }
+func BenchmarkProofOfWork16(b *testing.B) { benchmarkProofOfWork(b, DefaultProofOfWorkCost) }
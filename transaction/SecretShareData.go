@@ -21,10 +21,27 @@
 
 package transaction
 
+import (
+	"github.com/trusch/passchain/state"
+)
+
 type SecretShareData struct {
 	ID        string
 	SenderID  string
 	AccountID string
 	Key       string
-	IsOwner   bool
+	// IsOwner is only used by older clients, it grants the admin role
+	IsOwner bool
+	Role    state.Role `json:",omitempty"`
+}
+
+// GrantedRole returns the role the receiver of the share gets
+func (data *SecretShareData) GrantedRole() state.Role {
+	if data.Role != "" {
+		return data.Role
+	}
+	if data.IsOwner {
+		return state.RoleAdmin
+	}
+	return state.RoleReader
 }
@@ -22,11 +22,7 @@
 package transaction
 
 import (
-	"bytes"
-	"encoding/binary"
 	"encoding/json"
-	"errors"
-	"sort"
 	"time"
 
 	"github.com/trusch/passchain/crypto"
@@ -39,25 +35,39 @@ type Transaction struct {
 	Timestamp time.Time       `json:"timestamp"`
 	Signature string          `json:"signature"`
 	Nonce     uint32          `json:"nonce"`
+	Sequence  uint64          `json:"sequence"`
 	Data      interface{}     `json:"data"`
 }
 
-type Hashable interface {
-	Hash() []byte
-}
-
 type TransactionType string
 
 const (
-	AccountAdd     TransactionType = "add-account"
-	AccountDel     TransactionType = "del-account"
-	ReputationGive TransactionType = "give-reputation"
-	SecretAdd      TransactionType = "secret-add"
-	SecretUpdate   TransactionType = "secret-update"
-	SecretDel      TransactionType = "secret-del"
-	SecretShare    TransactionType = "secret-share"
+	AccountAdd             TransactionType = "add-account"
+	AccountDel             TransactionType = "del-account"
+	AccountRotateKey       TransactionType = "account-rotate-key"
+	AccountGuardiansSet    TransactionType = "account-guardians-set"
+	AccountRecover         TransactionType = "account-recover"
+	AccountRecoverCancel   TransactionType = "account-recover-cancel"
+	ReputationGive         TransactionType = "give-reputation"
+	SecretAdd              TransactionType = "secret-add"
+	SecretUpdate           TransactionType = "secret-update"
+	SecretDel              TransactionType = "secret-del"
+	SecretShare            TransactionType = "secret-share"
+	SecretUnshare          TransactionType = "secret-unshare"
+	SecretRotateKey        TransactionType = "secret-rotate-key"
+	SecretUnlockRequest    TransactionType = "secret-unlock-request"
+	SecretUnlockContribute TransactionType = "secret-unlock-contribute"
+	SecretUnlockClose      TransactionType = "secret-unlock-close"
+	SecretPropose          TransactionType = "secret-propose"
+	SecretApprove          TransactionType = "secret-approve"
+	GroupCreate            TransactionType = "group-create"
+	GroupMemberAdd         TransactionType = "group-member-add"
+	GroupMemberRemove      TransactionType = "group-member-remove"
+	ParamsSet              TransactionType = "params-set"
 )
 
+// DefaultProofOfWorkCost is the proof of work cost of chains which didn't configure one,
+// the current cost is part of the params of the chain
 const DefaultProofOfWorkCost byte = 16
 
 func (t *Transaction) FromBytes(bs []byte) error {
@@ -68,21 +78,29 @@ func (t *Transaction) ToBytes() ([]byte, error) {
 	return json.Marshal(t)
 }
 
-func (t *Transaction) Hash() []byte {
+// Hash returns the hash which is signed, it covers everything but the signature and nonce.
+// It fails if a part can't be encoded, so a signature never skips the data.
+func (t *Transaction) Hash() ([]byte, error) {
 	hash := sha3.New512()
 	encoder := json.NewEncoder(hash)
-	encoder.Encode(t.Type)
-	encoder.Encode(t.Timestamp)
-	if hashable, ok := t.Data.(Hashable); ok {
-		hash.Write(hashable.Hash())
-	} else {
-		encoder.Encode(t.Data)
+	for _, part := range []interface{}{t.Type, t.Timestamp, t.Sequence} {
+		if err := encoder.Encode(part); err != nil {
+			return nil, err
+		}
 	}
-	return hash.Sum(nil)
+	data, err := canonicalJSON(t.Data)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write(data)
+	return hash.Sum(nil), nil
 }
 
 func (t *Transaction) Sign(key *crypto.Key) error {
-	hash := t.Hash()
+	hash, err := t.Hash()
+	if err != nil {
+		return err
+	}
 	signature, err := key.Sign(hash)
 	if err != nil {
 		return err
@@ -92,50 +110,13 @@ func (t *Transaction) Sign(key *crypto.Key) error {
 }
 
 func (t *Transaction) Verify(key *crypto.Key) error {
-	hash := t.Hash()
-	return key.Verify(hash, t.Signature)
-}
-
-func (t *Transaction) ProofOfWork(cost byte) error {
-	for round := 0; round < (1 << 32); round++ {
-		t.Nonce = uint32(round)
-		if err := t.VerifyProofOfWork(cost); err == nil {
-			return nil
-		}
-	}
-	return errors.New("can not find pow")
-}
-
-func (t *Transaction) VerifyProofOfWork(cost byte) error {
-	hasher := sha3.New512()
-	hasher.Write(t.Hash())
-	binary.Write(hasher, binary.LittleEndian, t.Nonce)
-	tip := uint64(0)
-	buf := bytes.NewBuffer(hasher.Sum(nil))
-	binary.Read(buf, binary.LittleEndian, &tip)
-	if tip<<(64-cost) == 0 {
-		return nil
+	hash, err := t.Hash()
+	if err != nil {
+		return err
 	}
-	return errors.New("failed to validate proof of work")
+	return key.Verify(hash, t.Signature)
 }
 
 func New(t TransactionType, data interface{}) *Transaction {
-	return &Transaction{t, time.Now(), "", 0, data}
-}
-
-func hashStringMap(m map[string]interface{}) []byte {
-	hash := sha3.New512()
-	encoder := json.NewEncoder(hash)
-	keys := make([]string, len(m))
-	i := 0
-	for id := range m {
-		keys[i] = id
-		i++
-	}
-	sort.Strings(keys)
-	for _, key := range keys {
-		encoder.Encode(key)
-		encoder.Encode(m[key])
-	}
-	return hash.Sum(nil)
+	return &Transaction{Type: t, Timestamp: time.Now(), Data: data}
 }
@@ -1,6 +1,13 @@
 package transaction_test
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
 	"github.com/trusch/passchain/crypto"
 	"github.com/trusch/passchain/state"
 	. "github.com/trusch/passchain/transaction"
@@ -9,13 +16,179 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// mutate returns a value of the same type as v which differs from v
+func mutate(v reflect.Value) reflect.Value {
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		return reflect.ValueOf(v.Interface().(time.Time).Add(time.Hour))
+	}
+	res := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		res.SetString(v.String() + "x")
+	case reflect.Bool:
+		res.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		res.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		res.SetUint(v.Uint() + 1)
+	case reflect.Map:
+		res.Set(reflect.MakeMap(v.Type()))
+		for _, key := range v.MapKeys() {
+			res.SetMapIndex(key, v.MapIndex(key))
+		}
+		key := mutate(reflect.New(v.Type().Key()).Elem())
+		res.SetMapIndex(key, mutate(reflect.New(v.Type().Elem()).Elem()))
+	case reflect.Slice:
+		res.Set(reflect.Append(v, mutate(reflect.New(v.Type().Elem()).Elem())))
+	case reflect.Ptr:
+		if v.IsNil() {
+			res.Set(reflect.New(v.Type().Elem()))
+		} else {
+			res.Set(reflect.New(v.Type().Elem()))
+			res.Elem().Set(mutate(v.Elem()))
+		}
+	case reflect.Struct:
+		res.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				res.Field(i).Set(mutate(v.Field(i)))
+				break
+			}
+		}
+	default:
+		Fail("can not mutate values of kind " + v.Kind().String())
+	}
+	return res
+}
+
 var _ = Describe("Transaction", func() {
 	It("should be possible to find a PoW", func() {
 		t := New(AccountAdd, &AccountAddData{Account: &state.Account{}})
 		k, _ := crypto.CreateKeyPair()
 		Expect(t.Sign(k)).To(Succeed())
-		Expect(t.ProofOfWork(16)).To(Succeed())
+		_, err := t.ProofOfWork(context.Background(), 16)
+		Expect(err).NotTo(HaveOccurred())
 		Expect(t.Verify(k)).To(Succeed())
 		Expect(t.VerifyProofOfWork(16)).To(Succeed())
 	})
+
+	It("should charge the proof of work to the account whose sequence is used", func() {
+		for expected, t := range map[string]*Transaction{
+			"alice": New(AccountAdd, &AccountAddData{Account: &state.Account{ID: "alice"}}),
+			"bob":   New(AccountRotateKey, &AccountRotateKeyData{ID: "bob"}),
+			"carol": New(ReputationGive, &ReputationGiveData{From: "carol", To: "alice"}),
+			"dave":  New(AccountRecover, &AccountRecoverData{ID: "alice", SenderID: "dave"}),
+			"eve":   New(SecretShare, &SecretShareData{ID: "secret", SenderID: "eve", AccountID: "bob"}),
+		} {
+			Expect(t.ChargedAccount()).To(Equal(expected))
+			bs, err := t.ToBytes()
+			Expect(err).NotTo(HaveOccurred())
+			received := &Transaction{}
+			Expect(received.FromBytes(bs)).To(Succeed())
+			Expect(received.ChargedAccount()).To(Equal(expected))
+		}
+		_, err := New(AccountDel, &AccountDelData{}).ChargedAccount()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail on data which can't be encoded", func() {
+		t := New(AccountDel, map[string]interface{}{"ID": make(chan int)})
+		k, _ := crypto.CreateKeyPair()
+		_, err := t.Hash()
+		Expect(err).To(HaveOccurred())
+		Expect(t.Sign(k)).NotTo(Succeed())
+		Expect(t.Verify(k)).NotTo(Succeed())
+		_, err = t.ProofOfWork(context.Background(), 0)
+		Expect(err).To(HaveOccurred())
+		Expect(t.VerifyProofOfWork(0)).NotTo(Succeed())
+	})
+
+	It("should not be possible to change the sequence of a signed transaction", func() {
+		t := New(AccountDel, &AccountDelData{ID: "alice"})
+		t.Sequence = 1
+		k, _ := crypto.CreateKeyPair()
+		Expect(t.Sign(k)).To(Succeed())
+		t.Sequence = 2
+		Expect(t.Verify(k)).NotTo(Succeed())
+	})
+
+	It("should be possible to verify a transaction after sending it over the wire", func() {
+		t := New(SecretUpdate, &SecretUpdateData{
+			Secret: &state.Secret{
+				ID:     "id",
+				Value:  "value",
+				Shares: map[string]string{"alice": "key", "bob": "other key"},
+				Owners: map[string]bool{"alice": true},
+				Metadata: &state.SecretMetadata{
+					URL:    "https://example.com",
+					Tags:   []string{"web"},
+					Fields: map[string]string{"pin": "1234"},
+				},
+			},
+			SenderID: "alice",
+		})
+		k, _ := crypto.CreateKeyPair()
+		Expect(t.Sign(k)).To(Succeed())
+		bs, err := t.ToBytes()
+		Expect(err).NotTo(HaveOccurred())
+		received := &Transaction{}
+		Expect(received.FromBytes(bs)).To(Succeed())
+		Expect(received.Verify(k)).To(Succeed())
+	})
+
+	It("should be possible to sign a transaction which was written to a file", func() {
+		dir, err := ioutil.TempDir("", "passchain-tx")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "tx.json")
+		t := New(SecretAdd, &SecretAddData{
+			Secret:   &state.Secret{ID: "id", Value: "value", Shares: map[string]string{"alice": "key"}, ExpiresAt: 1792298763},
+			SenderID: "alice",
+		})
+		t.Sequence = 3
+		Expect(WriteFile(path, []*Transaction{t})).To(Succeed())
+		txs, err := ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(txs).To(HaveLen(1))
+		k, _ := crypto.CreateKeyPair()
+		Expect(txs[0].Sign(k)).To(Succeed())
+		hash, err := t.Hash()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(txs[0].Hash()).To(Equal(hash))
+		t.Signature = txs[0].Signature
+		Expect(t.Verify(k)).To(Succeed())
+	})
+
+	It("should not be possible to change any field of a signed secret", func() {
+		k, _ := crypto.CreateKeyPair()
+		secretType := reflect.TypeOf(state.Secret{})
+		for i := 0; i < secretType.NumField(); i++ {
+			secret := &state.Secret{
+				ID:     "id",
+				Value:  "value",
+				Shares: map[string]string{"alice": "key"},
+				Owners: map[string]bool{"alice": true},
+			}
+			for _, t := range []*Transaction{
+				New(SecretAdd, &SecretAddData{Secret: secret}),
+				New(SecretUpdate, &SecretUpdateData{Secret: secret, SenderID: "alice"}),
+			} {
+				Expect(t.Sign(k)).To(Succeed())
+				field := reflect.ValueOf(secret).Elem().Field(i)
+				original := reflect.ValueOf(field.Interface())
+				field.Set(mutate(field))
+				Expect(t.Verify(k)).NotTo(Succeed(), "field %v is not covered by the signature", secretType.Field(i).Name)
+				field.Set(original)
+			}
+		}
+	})
+
+	It("should not be possible to change the sender of a signed transaction", func() {
+		k, _ := crypto.CreateKeyPair()
+		data := &SecretShareData{ID: "id", SenderID: "alice", AccountID: "bob", Key: "key"}
+		t := New(SecretShare, data)
+		Expect(t.Sign(k)).To(Succeed())
+		data.SenderID = "mallory"
+		Expect(t.Verify(k)).NotTo(Succeed())
+	})
 })